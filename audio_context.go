@@ -2,6 +2,8 @@ package avebi
 
 import (
 	"errors"
+	"fmt"
+	"sync"
 
 	"github.com/erparts/reisen"
 	"github.com/hajimehoshi/ebiten/v2/audio"
@@ -20,6 +22,27 @@ func CreateAudioContextForMedia(videoFilename string) error {
 	if err != nil {
 		return err
 	}
+	return createAudioContext(sampleRate)
+}
+
+// Serializes createAudioContext() calls. Checking audio.CurrentContext()
+// and then calling audio.NewContext() is not atomic, and ebitengine panics
+// if a second context is created.
+var audioContextMutex sync.Mutex
+
+// Creates an ebitengine audio context with the given sample rate. Returns
+// [ErrNonNilAudioContext] if a context already exists, and [ErrBadSampleRate]
+// if the sample rate is not positive.
+func createAudioContext(sampleRate int) error {
+	if sampleRate <= 0 {
+		return fmt.Errorf("%w (%d)", ErrBadSampleRate, sampleRate)
+	}
+
+	audioContextMutex.Lock()
+	defer audioContextMutex.Unlock()
+	if audio.CurrentContext() != nil {
+		return ErrNonNilAudioContext
+	}
 	_ = audio.NewContext(sampleRate)
 	return nil
 }
@@ -38,3 +61,59 @@ func GetMediaAudioSampleRate(videoFilename string) (int, error) {
 
 	return audioStreams[0].SampleRate(), nil
 }
+
+// Volume multiplier shared by all players, see SetMasterVolume().
+var masterVolume = masterVolumeControl{volume: 1.0}
+
+type masterVolumeControl struct {
+	mutex       sync.Mutex
+	volume      float64
+	controllers map[*videoWithAudioController]struct{} // open controllers with audio
+}
+
+func (m *masterVolumeControl) get() float64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.volume
+}
+
+func (m *masterVolumeControl) register(controller *videoWithAudioController) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.controllers == nil {
+		m.controllers = make(map[*videoWithAudioController]struct{})
+	}
+	m.controllers[controller] = struct{}{}
+}
+
+func (m *masterVolumeControl) unregister(controller *videoWithAudioController) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.controllers, controller)
+}
+
+// Sets a volume multiplier in [0, 1] applied to all players, which
+// composes multiplicatively with the volume of each player (see
+// [Player.SetVolume]()). This allows lowering or ducking the audio of
+// a whole scene with a single control. Values outside the range are
+// clamped. The default is 1.
+func SetMasterVolume(volume float64) {
+	masterVolume.mutex.Lock()
+	masterVolume.volume = min(max(volume, 0.0), 1.0)
+	controllers := make([]*videoWithAudioController, 0, len(masterVolume.controllers))
+	for controller := range masterVolume.controllers {
+		controllers = append(controllers, controller)
+	}
+	masterVolume.mutex.Unlock()
+
+	// controllers lock the master volume while locked themselves, so
+	// they must be updated after unlocking to avoid lock order issues
+	for _, controller := range controllers {
+		controller.refreshVolume()
+	}
+}
+
+// Returns the volume multiplier applied to all players. See [SetMasterVolume]().
+func GetMasterVolume() float64 {
+	return masterVolume.get()
+}
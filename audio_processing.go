@@ -0,0 +1,264 @@
+package avebi
+
+import (
+	"math"
+	"time"
+)
+
+// Audio data served to ebitengine is always stereo L16 (16-bit signed
+// little-endian samples, interleaved left and right channels). reisen
+// converts all audio streams to this format internally.
+const (
+	audioChannelCount   = 2
+	audioBytesPerSample = 2
+	audioBytesPerFrame  = audioChannelCount * audioBytesPerSample
+	audioSampleMaxValue = math.MaxInt16
+	audioSampleMinValue = math.MinInt16
+)
+
+// Converts a length of L16 stereo data in bytes to its playback duration.
+func audioBytesToDuration(bytes int, sampleRate int) time.Duration {
+	frames := bytes / audioBytesPerFrame
+	return (time.Duration(frames) * time.Second) / time.Duration(sampleRate)
+}
+
+// Converts a playback duration to a length of L16 stereo data in bytes, rounded
+// down to whole sample frames.
+func durationToAudioBytes(duration time.Duration, sampleRate int) int {
+	frames := int((duration * time.Duration(sampleRate)) / time.Second)
+	return max(frames, 0) * audioBytesPerFrame
+}
+
+// Converts decibels to a linear gain. -Inf dB corresponds to 0.
+func dbToLinear(db float64) float64 {
+	return math.Pow(10, db/20.0)
+}
+
+// Converts a linear gain to decibels. 0 corresponds to -Inf dB.
+func linearToDB(gain float64) float64 {
+	if gain <= 0 {
+		return math.Inf(-1)
+	}
+	return 20.0 * math.Log10(gain)
+}
+
+// Returns the left and right channel gains for the given pan value
+// in [-1, +1]. Centered pan (0) keeps both channels at full gain, while
+// panning to one side progressively attenuates the opposite channel.
+func panGains(pan float64) (float64, float64) {
+	left, right := 1.0, 1.0
+	if pan > 0 {
+		left = 1.0 - pan
+	} else if pan < 0 {
+		right = 1.0 + pan
+	}
+	return left, right
+}
+
+// Swaps the left and right channels of the given L16 stereo data in place.
+func swapStereoL16(data []byte) {
+	for i := 0; i+audioBytesPerFrame <= len(data); i += audioBytesPerFrame {
+		data[i], data[i+2] = data[i+2], data[i]
+		data[i+1], data[i+3] = data[i+3], data[i+1]
+	}
+}
+
+// Decodes L16 data into the given samples slice, reusing its capacity.
+func decodeSamplesL16(samples []int16, data []byte) []int16 {
+	samples = samples[:0]
+	for i := 0; i+audioBytesPerSample <= len(data); i += audioBytesPerSample {
+		samples = append(samples, int16(uint16(data[i])|uint16(data[i+1])<<8))
+	}
+	return samples
+}
+
+// Encodes the given samples as L16 data. data must be big enough.
+func encodeSamplesL16(data []byte, samples []int16) {
+	for i, sample := range samples {
+		data[i*audioBytesPerSample] = byte(uint16(sample))
+		data[i*audioBytesPerSample+1] = byte(uint16(sample) >> 8)
+	}
+}
+
+// Rounds and clamps the given value to the valid sample range.
+func clampSample(value float64) int16 {
+	value = math.Round(value)
+	if value > audioSampleMaxValue {
+		return audioSampleMaxValue
+	} else if value < audioSampleMinValue {
+		return audioSampleMinValue
+	}
+	return int16(value)
+}
+
+// Scales the left and right channels of the given interleaved stereo
+// samples in place.
+func applyStereoGains(samples []int16, leftGain, rightGain float64) {
+	if leftGain == 1.0 && rightGain == 1.0 {
+		return
+	}
+
+	for i := 0; i+audioChannelCount <= len(samples); i += audioChannelCount {
+		samples[i] = clampSample(float64(samples[i]) * leftGain)
+		samples[i+1] = clampSample(float64(samples[i+1]) * rightGain)
+	}
+}
+
+// Center frequencies used by the bass and treble shelving filters.
+const (
+	bassShelfFrequency   = 250.0
+	trebleShelfFrequency = 4000.0
+	maxShelfGainDB       = 24.0
+)
+
+type shelfKind uint8
+
+const (
+	lowShelf shelfKind = iota
+	highShelf
+)
+
+// A biquad filter (direct form I) with independent state for each
+// audio channel. Coefficients follow the RBJ audio EQ cookbook.
+type biquadFilter struct {
+	active             bool
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     [audioChannelCount]float64
+}
+
+// Configures the filter as a shelving filter with a slope of 1. A gain
+// of 0dB deactivates the filter so it doesn't waste any processing.
+func (f *biquadFilter) setShelf(kind shelfKind, sampleRate int, frequency, gainDB float64) {
+	if gainDB == 0 {
+		f.active = false
+		f.reset()
+		return
+	}
+
+	frequency = min(frequency, float64(sampleRate)*0.45) // stay below nyquist
+	a := math.Pow(10, gainDB/40.0)
+	w0 := 2.0 * math.Pi * frequency / float64(sampleRate)
+	cosw0 := math.Cos(w0)
+	alpha := math.Sin(w0) / 2.0 * math.Sqrt2
+	sqrtA2Alpha := 2.0 * math.Sqrt(a) * alpha
+
+	var b0, b1, b2, a0, a1, a2 float64
+	switch kind {
+	case lowShelf:
+		b0 = a * ((a + 1) - (a-1)*cosw0 + sqrtA2Alpha)
+		b1 = 2 * a * ((a - 1) - (a+1)*cosw0)
+		b2 = a * ((a + 1) - (a-1)*cosw0 - sqrtA2Alpha)
+		a0 = (a + 1) + (a-1)*cosw0 + sqrtA2Alpha
+		a1 = -2 * ((a - 1) + (a+1)*cosw0)
+		a2 = (a + 1) + (a-1)*cosw0 - sqrtA2Alpha
+	case highShelf:
+		b0 = a * ((a + 1) + (a-1)*cosw0 + sqrtA2Alpha)
+		b1 = -2 * a * ((a - 1) + (a+1)*cosw0)
+		b2 = a * ((a + 1) + (a-1)*cosw0 - sqrtA2Alpha)
+		a0 = (a + 1) - (a-1)*cosw0 + sqrtA2Alpha
+		a1 = 2 * ((a - 1) - (a+1)*cosw0)
+		a2 = (a + 1) - (a-1)*cosw0 - sqrtA2Alpha
+	default:
+		panic("invalid shelf kind")
+	}
+
+	f.b0, f.b1, f.b2 = b0/a0, b1/a0, b2/a0
+	f.a1, f.a2 = a1/a0, a2/a0
+	f.active = true
+}
+
+// Clears the filter history, e.g. after a discontinuity in the audio.
+func (f *biquadFilter) reset() {
+	clear(f.x1[:])
+	clear(f.x2[:])
+	clear(f.y1[:])
+	clear(f.y2[:])
+}
+
+func (f *biquadFilter) process(channel int, x float64) float64 {
+	y := f.b0*x + f.b1*f.x1[channel] + f.b2*f.x2[channel] - f.a1*f.y1[channel] - f.a2*f.y2[channel]
+	f.x2[channel], f.x1[channel] = f.x1[channel], x
+	f.y2[channel], f.y1[channel] = f.y1[channel], y
+	return y
+}
+
+// Runs the active filters in series over the given interleaved stereo
+// samples, in place.
+func applyFilters(samples []int16, filters ...*biquadFilter) {
+	var anyActive bool
+	for _, filter := range filters {
+		anyActive = anyActive || filter.active
+	}
+	if !anyActive {
+		return
+	}
+
+	for i, sample := range samples {
+		channel := i % audioChannelCount
+		value := float64(sample)
+		for _, filter := range filters {
+			if filter.active {
+				value = filter.process(channel, value)
+			}
+		}
+		samples[i] = clampSample(value)
+	}
+}
+
+// Amount of sample frames used to compute each audio level measurement.
+// At 44.1kHz, this is about 23ms.
+const levelMeterWindowFrames = 1024
+
+// Measures RMS audio levels over consecutive windows of served audio.
+type levelMeter struct {
+	sumLeft, sumRight float64
+	frames            int
+	left, right       float64 // levels of the last complete window, in [0, 1]
+}
+
+// Adds the given L16 stereo data to the current measurement window.
+func (m *levelMeter) add(data []byte) {
+	for i := 0; i+audioBytesPerFrame <= len(data); i += audioBytesPerFrame {
+		left := float64(int16(uint16(data[i])|uint16(data[i+1])<<8)) / -audioSampleMinValue
+		right := float64(int16(uint16(data[i+2])|uint16(data[i+3])<<8)) / -audioSampleMinValue
+		m.sumLeft += left * left
+		m.sumRight += right * right
+		m.frames += 1
+		if m.frames == levelMeterWindowFrames {
+			m.left = math.Sqrt(m.sumLeft / levelMeterWindowFrames)
+			m.right = math.Sqrt(m.sumRight / levelMeterWindowFrames)
+			m.sumLeft, m.sumRight, m.frames = 0, 0, 0
+		}
+	}
+}
+
+func (m *levelMeter) reset() {
+	*m = levelMeter{}
+}
+
+// A linear gain ramp applied directly to L16 stereo data, used to fade
+// audio in and out without the clicks caused by sudden volume changes.
+type gainRamp struct {
+	gain float64 // current gain, in [0, 1]
+}
+
+// Moves the gain towards the target by step on each audio frame, scaling
+// the given data in place. Nothing is done once the gain reaches 1.
+func (r *gainRamp) apply(data []byte, target, step float64) {
+	if r.gain == 1.0 && target == 1.0 {
+		return
+	}
+
+	for i := 0; i+audioBytesPerFrame <= len(data); i += audioBytesPerFrame {
+		if r.gain < target {
+			r.gain = min(r.gain+step, target)
+		} else if r.gain > target {
+			r.gain = max(r.gain-step, target)
+		}
+		for j := i; j < i+audioBytesPerFrame; j += audioBytesPerSample {
+			sample := int16(uint16(data[j]) | uint16(data[j+1])<<8)
+			scaled := clampSample(float64(sample) * r.gain)
+			data[j], data[j+1] = byte(uint16(scaled)), byte(uint16(scaled)>>8)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package avebi
+
+import "encoding/binary"
+
+// Converts L16 stereo audio between sample rates with linear interpolation,
+// so media can be played on audio contexts with a different sample rate,
+// like the 48000Hz commonly imposed by browsers on 44100Hz files. Linear
+// interpolation is cheap and good enough for close rates, but it attenuates
+// high frequencies slightly and doesn't filter aliasing when downsampling.
+//
+// The state is kept between calls, so consecutive chunks of a stream are
+// converted seamlessly. It must be reset when the stream is rewound.
+type audioResampler struct {
+	inRate, outRate int
+
+	// position of the next output frame, in 1/outRate units of input
+	// frames, relative to prev (the last input frame of the previous
+	// chunk). only valid if hasPrev is true
+	phase   int
+	prev    [audioChannelCount]int
+	hasPrev bool
+
+	output []byte // reused between calls
+}
+
+func newAudioResampler(inRate, outRate int) *audioResampler {
+	return &audioResampler{inRate: inRate, outRate: outRate}
+}
+
+// Resamples the given chunk of input data, which must consist of whole
+// sample frames. The returned slice is only valid until the next call.
+func (r *audioResampler) resample(data []byte) []byte {
+	inFrames := len(data) / audioBytesPerFrame
+	if inFrames == 0 {
+		return data[:0]
+	}
+	if !r.hasPrev { // start exactly at the first input frame
+		for ch := range audioChannelCount {
+			r.prev[ch] = sampleAt(data, 0, ch)
+		}
+		r.phase = r.outRate
+		r.hasPrev = true
+	}
+
+	// the input is seen as prev followed by the chunk frames, so
+	// index i of that sequence is frame i-1 of the chunk
+	r.output = r.output[:0]
+	for r.phase < inFrames*r.outRate {
+		index, frac := r.phase/r.outRate, r.phase%r.outRate
+		for ch := range audioChannelCount {
+			from := r.prev[ch]
+			if index > 0 {
+				from = sampleAt(data, index-1, ch)
+			}
+			to := sampleAt(data, index, ch)
+			sample := from + ((to-from)*frac)/r.outRate
+			r.output = binary.LittleEndian.AppendUint16(r.output, uint16(int16(sample)))
+		}
+		r.phase += r.inRate
+	}
+
+	for ch := range audioChannelCount {
+		r.prev[ch] = sampleAt(data, inFrames-1, ch)
+	}
+	r.phase -= inFrames * r.outRate
+	return r.output
+}
+
+// Discards the interpolation state, for discontinuities in the input.
+func (r *audioResampler) reset() {
+	r.hasPrev = false
+}
+
+// Returns the sample of the given channel and frame of L16 stereo data.
+func sampleAt(data []byte, frame, channel int) int {
+	offset := frame*audioBytesPerFrame + channel*audioBytesPerSample
+	return int(int16(binary.LittleEndian.Uint16(data[offset:])))
+}
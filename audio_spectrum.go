@@ -0,0 +1,130 @@
+package avebi
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// Default configuration for [Player.AudioSpectrum]().
+const (
+	defaultSpectrumWindowSize = 2048
+	defaultSpectrumSmoothing  = 0.5
+	spectrumMinFrequency      = 20.0
+)
+
+// Keeps a window of the most recent served audio (mixed down to mono)
+// and computes band magnitudes from it with an FFT.
+type spectrumAnalyzer struct {
+	samples   []float64 // ring buffer, len is the window size (a power of 2)
+	next      int       // next index to write in samples
+	smoothing float64
+	smoothed  []float64 // previous band magnitudes
+
+	// scratch buffers
+	hann []float64
+	fft  []complex128
+}
+
+// Configures the analyzer. The window size is rounded up to the next
+// power of two. Changing the configuration discards the captured audio.
+func (a *spectrumAnalyzer) configure(windowSize int, smoothing float64) {
+	windowSize = 1 << bits.Len(uint(max(windowSize, 2)-1))
+	a.samples = make([]float64, windowSize)
+	a.next = 0
+	a.smoothing = min(max(smoothing, 0), 0.99)
+	a.smoothed = nil
+	a.hann = make([]float64, windowSize)
+	for i := range a.hann {
+		a.hann[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(windowSize-1))
+	}
+	a.fft = make([]complex128, windowSize)
+}
+
+func (a *spectrumAnalyzer) enabled() bool {
+	return a.samples != nil
+}
+
+// Adds the given L16 stereo data to the window.
+func (a *spectrumAnalyzer) add(data []byte) {
+	if !a.enabled() {
+		return
+	}
+	for i := 0; i+audioBytesPerFrame <= len(data); i += audioBytesPerFrame {
+		left := float64(int16(uint16(data[i]) | uint16(data[i+1])<<8))
+		right := float64(int16(uint16(data[i+2]) | uint16(data[i+3])<<8))
+		a.samples[a.next] = (left + right) / (2 * -audioSampleMinValue)
+		a.next = (a.next + 1) & (len(a.samples) - 1)
+	}
+}
+
+// Clears the captured audio, e.g. when audio stops.
+func (a *spectrumAnalyzer) reset() {
+	clear(a.samples)
+	a.smoothed = nil
+}
+
+// Returns the magnitudes for the given amount of logarithmically spaced
+// frequency bands, from 20Hz up to the nyquist frequency.
+func (a *spectrumAnalyzer) bands(count int, sampleRate int) []float64 {
+	// apply the window function, oldest sample first, and transform
+	size := len(a.samples)
+	for i := range size {
+		a.fft[i] = complex(a.samples[(a.next+i)&(size-1)]*a.hann[i], 0)
+	}
+	fftInPlace(a.fft)
+
+	// aggregate bins into bands
+	bins := size / 2
+	binWidth := float64(sampleRate) / float64(size)
+	nyquist := float64(sampleRate) / 2
+	ratio := math.Pow(nyquist/spectrumMinFrequency, 1/float64(count))
+	magnitudes := make([]float64, count)
+	low := spectrumMinFrequency
+	for band := range magnitudes {
+		high := low * ratio
+		first := min(int(low/binWidth), bins-1)
+		last := min(max(int(high/binWidth), first+1), bins)
+		var peak float64
+		for bin := first; bin < last; bin++ {
+			peak = max(peak, cmplx.Abs(a.fft[bin]))
+		}
+		magnitudes[band] = min(peak/(float64(size)/4), 1) // hann window halves the gain
+		low = high
+	}
+
+	// smooth the results over time
+	if len(a.smoothed) != count {
+		a.smoothed = make([]float64, count)
+		copy(a.smoothed, magnitudes)
+	}
+	for i, magnitude := range magnitudes {
+		a.smoothed[i] = a.smoothed[i]*a.smoothing + magnitude*(1-a.smoothing)
+	}
+	return append(magnitudes[:0], a.smoothed...)
+}
+
+// Iterative radix-2 FFT. The length of values must be a power of two.
+func fftInPlace(values []complex128) {
+	n := len(values)
+	shift := 64 - bits.Len(uint(n-1))
+	for i := range n {
+		j := int(bits.Reverse64(uint64(i)) >> shift)
+		if i < j {
+			values[i], values[j] = values[j], values[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := range size / 2 {
+				even, odd := values[start+k], values[start+k+size/2]*w
+				values[start+k] = even + odd
+				values[start+k+size/2] = even - odd
+				w *= step
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package avebi
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/erparts/reisen"
+)
+
+// Information about an audio track of the media. See [Player.AudioTracks]().
+type AudioTrackInfo struct {
+	// Index of the track among the audio tracks of the media, as used
+	// by [PlayerOptions].AudioTrack and [Player.SetAudioTrack]().
+	Index int
+
+	// Language tag of the track (e.g. "eng", "jpn"), or empty if unknown.
+	// reisen doesn't expose stream metadata yet, so this is always empty
+	// at the moment.
+	Language string
+
+	// Short name of the audio codec (e.g. "aac", "opus").
+	Codec string
+
+	// Amount of channels and sample rate of the encoded audio. Notice that
+	// the audio is always played as stereo, and the sample rate must match
+	// the audio context.
+	ChannelCount int
+	SampleRate   int
+
+	// Whether this is the track being played.
+	Active bool
+}
+
+// Collects the audio track information of the given media. activeTrack
+// is the index of the track being played, or -1 if none.
+func audioTrackInfos(container *reisen.Media, activeTrack int) []AudioTrackInfo {
+	audioStreams := container.AudioStreams()
+	tracks := make([]AudioTrackInfo, 0, len(audioStreams))
+	for i, stream := range audioStreams {
+		tracks = append(tracks, AudioTrackInfo{
+			Index:        i,
+			Codec:        stream.CodecName(),
+			ChannelCount: stream.ChannelCount(),
+			SampleRate:   stream.SampleRate(),
+			Active:       i == activeTrack,
+		})
+	}
+	return tracks
+}
+
+// Collects the audio track information for a player with the given
+// controller. Live streams don't support audio, so they report no tracks.
+func playerAudioTracks(container *reisen.Media, controller VideoController, opts PlayerOptions, streamOpts *StreamOptions) []AudioTrackInfo {
+	if streamOpts != nil {
+		return nil
+	}
+	activeTrack := -1
+	if _, isVideoWithAudio := controller.(*videoWithAudioController); isVideoWithAudio {
+		activeTrack = opts.AudioTrack
+	}
+	return audioTrackInfos(container, activeTrack)
+}
+
+// Returns the audio stream selected by [PlayerOptions].AudioTrack.
+func selectAudioStream(audioStreams []*reisen.AudioStream, opts PlayerOptions) (*reisen.AudioStream, error) {
+	if opts.AudioTrack < 0 || opts.AudioTrack >= len(audioStreams) {
+		return nil, fmt.Errorf("audio track %d not found, the media has %d audio tracks", opts.AudioTrack, len(audioStreams))
+	}
+	return audioStreams[opts.AudioTrack], nil
+}
+
+// Returns information about the audio tracks of the media, like codecs and
+// languages, which is useful to build track selection menus. The track to
+// play can be chosen with [PlayerOptions].AudioTrack when creating a player,
+// or changed later with [Player.SetAudioTrack]().
+// Live streams and players created from custom controllers return nil.
+func (p *Player) AudioTracks() []AudioTrackInfo {
+	return slices.Clone(p.audioTracks)
+}
+
+// Switches to the audio track with the given index (see [Player.AudioTracks]()),
+// continuing from the current position and keeping the playing or paused
+// state. The source is reopened like with [Player.SwitchSource](), but the
+// audio configuration (volume, mute, equalizer, etc.) is preserved. Selecting
+// the track being played does nothing.
+//
+// This is only available for players created from a file or URL. The
+// selected track is also used by [Player.Clone]().
+func (p *Player) SetAudioTrack(index int) error {
+	if p.closed {
+		return ErrPlayerClosed
+	}
+	if p.source == "" || p.IsLive() {
+		return fmt.Errorf("audio tracks: %w", ErrNoSource)
+	}
+	if index < 0 || index >= len(p.audioTracks) {
+		return fmt.Errorf("audio track %d not found, the media has %d audio tracks", index, len(p.audioTracks))
+	}
+	if p.audioTracks[index].Active {
+		return nil
+	}
+
+	prevTrack := p.options.AudioTrack
+	p.options.AudioTrack = index
+	err := p.switchSource(p.source, true)
+	if err != nil {
+		p.options.AudioTrack = prevTrack
+	}
+	return err
+}
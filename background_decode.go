@@ -0,0 +1,113 @@
+package avebi
+
+import (
+	"sync"
+
+	"github.com/erparts/reisen"
+)
+
+// Amount of frames the background decoder can decode ahead of the frames
+// consumed by the controller. See PlayerOptions.BackgroundDecoding.
+const backgroundDecodeQueueSize = 4
+
+// Decodes video frames ahead of time on a separate goroutine, so the
+// controller doesn't block on I/O while decoded frames are available.
+//
+// The decoding goroutine is the only one accessing the stream while it's
+// running, so the decoder must be stopped before rewinding or closing the
+// stream, and started again afterwards. The decoder itself is not safe for
+// concurrent use: it's protected by the controller mutex.
+type backgroundDecoder struct {
+	read    func() (*reisen.VideoFrame, error) // returns a nil frame at the end
+	running bool
+	stopCh  chan struct{}
+	results chan decodeResult
+	pending *decodeResult // received by ready(), but not consumed yet
+	wg      sync.WaitGroup
+}
+
+type decodeResult struct {
+	frame *reisen.VideoFrame
+	err   error
+}
+
+func newBackgroundDecoder(read func() (*reisen.VideoFrame, error)) *backgroundDecoder {
+	return &backgroundDecoder{read: read}
+}
+
+// Starts decoding from the current stream position. Does nothing if
+// the decoder is already running.
+func (d *backgroundDecoder) start() {
+	if d.running {
+		return
+	}
+	d.running = true
+	d.stopCh = make(chan struct{})
+	d.results = make(chan decodeResult, backgroundDecodeQueueSize)
+	d.wg.Add(1)
+	go d.decodeLoop(d.stopCh, d.results)
+}
+
+func (d *backgroundDecoder) decodeLoop(stopCh <-chan struct{}, results chan<- decodeResult) {
+	defer d.wg.Done()
+	for {
+		frame, err := d.read()
+		select {
+		case results <- decodeResult{frame, err}:
+		case <-stopCh:
+			return
+		}
+		if frame == nil || err != nil {
+			return // end of stream or error, started again after rewinding
+		}
+	}
+}
+
+// Stops the decoding goroutine, waiting for any ongoing read to finish,
+// and discards the frames decoded ahead. Does nothing if the decoder is
+// not running.
+func (d *backgroundDecoder) stop() {
+	if !d.running {
+		return
+	}
+	close(d.stopCh)
+	d.wg.Wait()
+	d.running = false
+	d.results = nil
+	d.pending = nil
+}
+
+// Returns whether next() can return without blocking.
+func (d *backgroundDecoder) ready() bool {
+	if d.pending != nil || !d.running {
+		return true
+	}
+	select {
+	case result := <-d.results:
+		d.pending = &result
+		return true
+	default:
+		return false
+	}
+}
+
+// Returns the next decoded frame, waiting for it if necessary. Like the
+// read function, a nil frame means the end of the stream. If the decoder
+// is not running, the frame is read directly.
+func (d *backgroundDecoder) next() (*reisen.VideoFrame, error) {
+	if !d.running {
+		return d.read()
+	}
+
+	var result decodeResult
+	if d.pending != nil {
+		result, d.pending = *d.pending, nil
+	} else {
+		result = <-d.results
+	}
+	if result.frame == nil || result.err != nil {
+		// the goroutine is done, so later reads can go directly
+		d.stop()
+	}
+	return result.frame, result.err
+}
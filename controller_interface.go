@@ -1,14 +1,29 @@
 package avebi
 
 import (
+	"math"
+	"sync"
 	"time"
 
 	"github.com/erparts/reisen"
 )
 
 // A common interface that helps us control the timing and position
-// of the video.
-type videoController interface {
+// of the video. The package provides implementations for videos with
+// and without audio and for live streams, which are selected automatically
+// by the [Player] constructors.
+//
+// Most users don't need to care about this interface. It's exported so
+// custom implementations can be passed to [NewPlayerWithController](),
+// typically fakes returning scripted states and positions in order to
+// test code built on top of [Player] without real media files nor an
+// audio context. Notice that [reisen.VideoFrame] values can't be created
+// outside reisen, so fakes will generally return nil frames, which the
+// player presents as black frames.
+//
+// Implementations must be safe for concurrent use. Methods may be added
+// to this interface in the future as the [Player] API grows.
+type VideoController interface {
 	// --- playback state ---
 
 	// Returns the playback state: [Stopped], [Playing] or [Paused].
@@ -25,12 +40,20 @@ type videoController interface {
 	// Stops playing and rewinds to position 0.
 	Stop() error
 
+	// Opens the video and decodes its first frame without starting playback,
+	// leaving the video [Paused] at position 0. If the video is not [Stopped],
+	// it does nothing and returns a nil frame.
+	Prime() (*reisen.VideoFrame, error)
+
 	// Permanently closes the video. The controller becomes unusable after this.
 	Close() error
 
 	// Moves to the specified position. The playing/paused state should be unaffected.
 	Seek(time.Duration) (*reisen.VideoFrame, error)
 
+	// Returns whether Seek() is supported by the controller.
+	IsSeekable() bool
+
 	// --- timing ---
 
 	// Returns the current playback position. If the video is [Stopped],
@@ -48,6 +71,25 @@ type videoController interface {
 	// Gets whether the video is configured to loop or not. See SetLooping().
 	GetLooping() bool
 
+	// Sets the position the video rewinds to when looping. The first
+	// playthrough still starts at 0.
+	SetLoopStart(time.Duration)
+
+	// Gets the position the video rewinds to when looping. See SetLoopStart().
+	GetLoopStart() time.Duration
+
+	// --- diagnostics ---
+
+	// Returns the amount of decoded video frames that were discarded without
+	// ever being returned by CurrentVideoFrame(), typically because frames
+	// were not requested often enough to keep up with the video frame rate.
+	DroppedFrameCount() int
+
+	// Sets a function to be called with errors that happen outside of
+	// controller method calls, like background decoding errors. The handler
+	// is never called while holding the controller mutex. Nil removes it.
+	SetErrorHandler(func(error))
+
 	// --- raw methods for reisen values ---
 
 	// Returns the current video frame, and whether we reached the end of the video.
@@ -58,6 +100,12 @@ type videoController interface {
 	Error() error
 }
 
+// Time source for all the wall clock based timing in the controllers.
+// Tests can replace it to control the passing of time deterministically
+// instead of sleeping, but it must not be replaced while controllers are
+// in use from other goroutines.
+var nowFunc = time.Now
+
 // aux type for noLockStop operations on both video only and standard video controllers
 type stopMode bool
 
@@ -65,3 +113,115 @@ const (
 	stopModeManual     stopMode = true
 	stopModeEndOfVideo stopMode = false
 )
+
+// maximum amount of video frames decoded when the end of the video is
+// detected before the video stream is exhausted (e.g. due to a large gap
+// between updates), so the last frame is presented consistently without
+// risking long stalls. see noLockExhaustVideoFrames() on the controllers
+const maxEndOfVideoFrames = 16
+
+// minimum window at the end of the video where decode errors can be
+// tolerated, see PlayerOptions.TolerateTruncatedEnd
+const truncationToleranceWindow = 2 * time.Second
+
+// aux function for PlayerOptions.TolerateTruncatedEnd on both video only and
+// standard video controllers. returns whether a decode error found after
+// decoding a frame at the given position looks like a truncated end of file
+func isTruncatedEnd(lastDecoded, duration time.Duration) bool {
+	window := max(duration/20, truncationToleranceWindow)
+	return lastDecoded >= duration-window
+}
+
+// aux function for PlayerOptions.DecodeScale, MaxWidth and MaxHeight on both
+// video only and standard video controllers, and the player. returns the
+// resolution of the frames that will be produced for the given video stream.
+func decodeResolution(stream *reisen.VideoStream, opts PlayerOptions) (int, int) {
+	width, height := float64(stream.Width()), float64(stream.Height())
+	scale := 1.0
+	if opts.DecodeScale > 0 && opts.DecodeScale < 1 {
+		scale = opts.DecodeScale
+	}
+
+	// the caps are applied after the scale, keeping the aspect ratio
+	if opts.MaxWidth > 0 && width*scale > float64(opts.MaxWidth) {
+		scale = float64(opts.MaxWidth) / width
+	}
+	if opts.MaxHeight > 0 && height*scale > float64(opts.MaxHeight) {
+		scale = float64(opts.MaxHeight) / height
+	}
+	if scale == 1.0 {
+		return stream.Width(), stream.Height()
+	}
+
+	scaledWidth := max(int(math.Round(width*scale)), 1)
+	scaledHeight := max(int(math.Round(height*scale)), 1)
+	if opts.MaxWidth > 0 { // rounding must not exceed the caps
+		scaledWidth = min(scaledWidth, opts.MaxWidth)
+	}
+	if opts.MaxHeight > 0 {
+		scaledHeight = min(scaledHeight, opts.MaxHeight)
+	}
+	return scaledWidth, scaledHeight
+}
+
+// aux function to open a video stream for decoding at the given resolution,
+// as returned by decodeResolution(). area averaging is used for scaling, as
+// it gives the best results for downscaling
+func openVideoDecode(stream *reisen.VideoStream, width, height int) error {
+	if width == stream.Width() && height == stream.Height() {
+		return stream.Open()
+	}
+	return stream.OpenDecode(width, height, reisen.InterpolationArea)
+}
+
+// aux function for SetLoopStart() on both video only and standard video controllers.
+// the loop start must leave at least one frame to play before reaching the end.
+func clampLoopStart(position, duration, frameDuration time.Duration) time.Duration {
+	return max(min(position, duration-frameDuration), 0)
+}
+
+// aux type to deliver errors happening outside of user calls (e.g. in
+// decoding goroutines or ebitengine audio reads) to an optional handler.
+// it has its own mutex so the handler is never invoked while holding a
+// controller mutex, which would make re-entrant calls deadlock
+type errorReporter struct {
+	mutex   sync.Mutex
+	handler func(error)
+	pending []error
+}
+
+func (r *errorReporter) setHandler(handler func(error)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.handler = handler
+}
+
+// Queues an error to be delivered on the next flush(). Safe to call
+// while holding a controller mutex.
+func (r *errorReporter) queue(err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.handler != nil {
+		r.pending = append(r.pending, err)
+	}
+}
+
+// Delivers any queued errors to the handler. Must not be called while
+// holding a controller mutex.
+func (r *errorReporter) flush() {
+	r.mutex.Lock()
+	handler, pending := r.handler, r.pending
+	r.pending = nil
+	r.mutex.Unlock()
+
+	for _, err := range pending {
+		handler(err)
+	}
+}
+
+// Delivers the error to the handler immediately. Must not be called
+// while holding a controller mutex.
+func (r *errorReporter) report(err error) {
+	r.queue(err)
+	r.flush()
+}
@@ -1,6 +1,7 @@
 package avebi
 
 import (
+	"errors"
 	"sync"
 	"time"
 
@@ -9,7 +10,7 @@ import (
 
 // TODO: looping logic not implemented
 
-var _ videoController = (*videoOnlyController)(nil)
+var _ VideoController = (*videoOnlyController)(nil)
 
 type videoOnlyController struct {
 	// mutex and underlying reisen objects
@@ -19,41 +20,101 @@ type videoOnlyController struct {
 
 	// static data
 	duration      time.Duration // complete video duration
-	frameDuration time.Duration
+	frameDuration time.Duration // nominal, frames can last longer if variableFrameRate
+	decodeWidth   int           // see PlayerOptions.DecodeScale
+	decodeHeight  int
+
+	// whether frame durations must be taken from the timestamps of the
+	// frames themselves instead of frameDuration, like in GIFs
+	variableFrameRate bool
 
 	// state variables
 	referenceTime     time.Time
 	referencePosition time.Duration
 	looping           bool
+	loopStart         time.Duration
+	endBehavior       EndBehavior
 	videoPendingLoop  bool
+	loopCount         int // total loops, never reset
 	state             PlaybackState
 	lastReadFrame     *reisen.VideoFrame
+
+	// frames decoded but skipped while catching up with the position
+	droppedFrames int
+	decodeStats   decodeStatsCollector
+
+	// decoded frames following lastReadFrame, up to prefetchDepth
+	prefetchDepth int
+	prefetched    []*reisen.VideoFrame
+
+	// nil unless PlayerOptions.BackgroundDecoding is set
+	decoder *backgroundDecoder
+
+	// catch-up behavior after large gaps between frame requests
+	catchUpStrategy  CatchUpStrategy
+	catchUpThreshold time.Duration
+	skipGap          bool // seek on the next large gap regardless of the strategy
+
+	// truncated files tolerance, see PlayerOptions.TolerateTruncatedEnd
+	tolerateTruncatedEnd bool
+	lastDecodedOffset    time.Duration
+
+	// notified on each state change, see Player.WaitForState()
+	stateSignal stateSignal
+
+	// shared with the player, see Player.SetLogPrefix()
+	logger *playerLogger
 }
 
-func newVideoOnlyController(media *reisen.Media, videoStream *reisen.VideoStream) (videoController, error) {
+func newVideoOnlyController(media *reisen.Media, videoStream *reisen.VideoStream, opts PlayerOptions, logger *playerLogger) (VideoController, error) {
 	if media == nil || videoStream == nil {
 		panic("nil media or video stream")
 	}
 
-	frNum, frDenom := videoStream.FrameRate()
-	frameDuration := (time.Second * time.Duration(frDenom)) / time.Duration(frNum)
+	frameDuration := nominalFrameDuration(videoStream)
 	duration, err := videoStream.Duration()
 	if err != nil {
 		return nil, err
 	}
+	if duration <= 0 {
+		// some containers, like GIF, only declare the global duration
+		duration, err = media.Duration()
+		if err != nil {
+			return nil, err
+		}
+		duration = max(duration, 0)
+	}
 
+	// GIFs have per-frame delays instead of a constant frame rate, and
+	// they are expected to loop, like in browsers and image viewers
+	isGIF := media.FormatName() == "gif"
+
+	decodeWidth, decodeHeight := decodeResolution(videoStream, opts)
 	controller := &videoOnlyController{
 		// underlying reisen objects
 		media:  media,
 		stream: videoStream,
 
 		// static values
-		duration:      duration,
-		frameDuration: frameDuration,
+		duration:          duration,
+		frameDuration:     frameDuration,
+		decodeWidth:       decodeWidth,
+		decodeHeight:      decodeHeight,
+		variableFrameRate: isGIF,
 
 		// state variables
-		referenceTime: time.Now(),
-		state:         Stopped,
+		referenceTime:        nowFunc(),
+		looping:              isGIF,
+		state:                Stopped,
+		prefetchDepth:        max(opts.PrefetchDepth, 0),
+		tolerateTruncatedEnd: opts.TolerateTruncatedEnd,
+		logger:               logger,
+	}
+	if controller.prefetchDepth > 0 {
+		controller.prefetched = make([]*reisen.VideoFrame, 0, controller.prefetchDepth)
+	}
+	if opts.BackgroundDecoding {
+		controller.decoder = newBackgroundDecoder(controller.decodeVideoFrame)
 	}
 	return controller, nil
 }
@@ -61,38 +122,115 @@ func newVideoOnlyController(media *reisen.Media, videoStream *reisen.VideoStream
 func (c *videoOnlyController) Play() error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
+	return c.noLockPlay()
+}
+
+// preconditions: c.mutex is locked
+func (c *videoOnlyController) noLockPlay() error {
 	if c.state != Playing {
 		if c.state == Stopped {
-			c.lastReadFrame = nil
 			c.referencePosition = 0 // necessary if we had a natural end-of-video stop
-			err := c.media.OpenDecode()
+			err := c.noLockOpen()
 			if err != nil {
 				return err
 			}
-			err = c.stream.Open()
+		} else if c.referencePosition >= c.duration {
+			// paused at the end due to EndPauseAtEnd, restart
+			err := c.noLockRewind(0)
 			if err != nil {
 				return err
 			}
+			c.referencePosition = 0
+			c.lastReadFrame = nil
 		}
 
-		c.referenceTime = time.Now()
+		c.referenceTime = nowFunc()
 		c.state = Playing
+		c.stateSignal.notify()
+	}
+	return nil
+}
+
+func (c *videoOnlyController) Restart() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.state != Stopped {
+		// same rewind used for looping, the streams remain open
+		err := c.noLockRewind(0)
+		if err != nil {
+			return err
+		}
+		c.referencePosition = 0
+		c.lastReadFrame = nil
+		c.videoPendingLoop = false
+		c.state = Paused
+		c.stateSignal.notify()
+	}
+	return c.noLockPlay()
+}
+
+func (c *videoOnlyController) Prime() (*reisen.VideoFrame, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.state != Stopped {
+		return nil, nil
+	}
+
+	c.referencePosition = 0
+	err := c.noLockOpen()
+	if err != nil {
+		return nil, err
+	}
+
+	// from now on we are paused, so Play() won't try to reopen the streams
+	c.referenceTime = nowFunc()
+	c.state = Paused
+	c.stateSignal.notify()
+	c.lastReadFrame, err = c.internalReadVideoFrame()
+	return c.lastReadFrame, err
+}
+
+// Opens the media and video stream for decoding. Only valid while
+// the controller is [Stopped], as Stop() closes them.
+// preconditions: c.mutex is locked
+func (c *videoOnlyController) noLockOpen() error {
+	c.lastReadFrame = nil
+	err := c.media.OpenDecode()
+	if err != nil {
+		return err
+	}
+	err = openVideoDecode(c.stream, c.decodeWidth, c.decodeHeight)
+	if err != nil {
+		return err
+	}
+	if c.decoder != nil {
+		c.decoder.start()
 	}
 	return nil
 }
 
+func (c *videoOnlyController) stateChanged() <-chan struct{} {
+	return c.stateSignal.changed()
+}
+
 func (c *videoOnlyController) State() (PlaybackState, error) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	// we call c.noLockPosition for its side-effects: if the
 	// video has reached the end, that will be detected and
 	// reflected on c.state
-	if _, _, err := c.noLockPosition(time.Now()); err != nil {
+	if _, _, err := c.noLockPosition(nowFunc()); err != nil {
 		return invalidPlaybackState, err
 	}
 	return c.state, nil
 }
 
+func (c *videoOnlyController) PeekState() PlaybackState {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.state
+}
+
 func (c *videoOnlyController) Pause() error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
@@ -100,13 +238,14 @@ func (c *videoOnlyController) Pause() error {
 		return nil
 	}
 
-	now := time.Now()
+	now := nowFunc()
 	position, endedAsSideEffect, err := c.noLockPosition(now)
 	if err != nil {
 		return err
 	}
 	if !endedAsSideEffect {
 		c.state = Paused
+		c.stateSignal.notify()
 		c.referenceTime = now
 		c.referencePosition = position
 	}
@@ -121,7 +260,7 @@ func (c *videoOnlyController) Pause() error {
 // the duration of the video
 func (c *videoOnlyController) noLockPosition(now time.Time) (time.Duration, bool, error) {
 	if c.referenceTime.After(now) {
-		pkgLogger.Printf("WARNING: time inconsistency, video reference time after time.Now()")
+		c.logger.Printf("WARNING: time inconsistency, video reference time after current time")
 		now = c.referenceTime
 	}
 
@@ -133,25 +272,19 @@ func (c *videoOnlyController) noLockPosition(now time.Time) (time.Duration, bool
 
 		// consider looping case
 		if c.looping {
-			err := c.stream.Rewind(0)
+			err := c.noLockRewind(c.loopStart)
 			if err != nil {
 				return position, false, err
 			}
 			c.referenceTime = now
-			c.referencePosition = position - c.duration
+			c.referencePosition = c.loopStart + (position - c.duration)
 			c.videoPendingLoop = true
+			c.loopCount += 1
 			return c.referencePosition, false, nil
 		}
 
-		// here exhausting video frames to fetch the latest one could be reasonable,
-		// but it also comes with some risks, and in practice should be limited to
-		// a maximum decoding of only a few frames. we are avoiding this whole process
-		// at the moment for simplicity
-		// c.exhaustVideoFrames()
-
-		err := c.noLockStop(stopModeEndOfVideo)
-		c.referencePosition = c.duration
-		return c.referencePosition, true, err
+		err := c.noLockEndOfVideo(now)
+		return c.duration, true, err
 	} else {
 		return c.referencePosition, false, nil
 	}
@@ -198,15 +331,14 @@ func (c *videoOnlyController) noLockStop(videoStopMode stopMode) error {
 
 	// stopping logic
 	c.state = Stopped
+	c.stateSignal.notify()
 	c.referenceTime = time.Time{}
 	if videoStopMode == stopModeEndOfVideo {
 		c.referencePosition = c.duration
-		// we don't clear lastReadFrame. in fact, we might
-		// want to exhaust the frames to reach the last one,
-		// but for the time being we are avoiding this for
-		// simplicity
+		// we don't clear lastReadFrame, as it's the last frame
+		// of the video after noLockExhaustVideoFrames()
 	}
-	err := c.stream.Rewind(0)
+	err := c.noLockRewind(0)
 	if err != nil {
 		return err
 	}
@@ -218,10 +350,51 @@ func (c *videoOnlyController) noLockStop(videoStopMode stopMode) error {
 	return c.media.CloseDecode()
 }
 
+// Handles the natural end of the video according to c.endBehavior.
+// preconditions: c.mutex is locked
+func (c *videoOnlyController) noLockEndOfVideo(now time.Time) error {
+	exhaustErr := c.noLockExhaustVideoFrames()
+	return errors.Join(exhaustErr, c.noLockHandleEndBehavior(now))
+}
+
+// Decodes the frames remaining at the end of the video, up to
+// maxEndOfVideoFrames, and keeps the latest one as lastReadFrame, so
+// the video always freezes on its last frame, regardless of whether
+// the end was detected through the clock or through the stream.
+// preconditions: c.mutex is locked
+func (c *videoOnlyController) noLockExhaustVideoFrames() error {
+	var advanced bool
+	for range maxEndOfVideoFrames {
+		frame, err := c.noLockNextVideoFrame()
+		if err != nil || frame == nil {
+			return err
+		}
+		if advanced { // previous frame is being replaced before being returned
+			c.droppedFrames += 1
+		}
+		c.lastReadFrame = frame
+		advanced = true
+	}
+	return nil
+}
+
+// preconditions: c.mutex is locked
+func (c *videoOnlyController) noLockHandleEndBehavior(now time.Time) error {
+	if c.endBehavior == EndPauseAtEnd {
+		c.state = Paused
+		c.stateSignal.notify()
+		c.referenceTime = now
+		c.referencePosition = c.duration
+		c.videoPendingLoop = false
+		return nil
+	}
+	return c.noLockStop(stopModeEndOfVideo)
+}
+
 func (c *videoOnlyController) Position() (time.Duration, error) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
-	position, _, err := c.noLockPosition(time.Now())
+	position, _, err := c.noLockPosition(nowFunc())
 	return position, err
 }
 
@@ -242,19 +415,141 @@ func (c *videoOnlyController) Seek(position time.Duration) (*reisen.VideoFrame,
 		err := c.noLockStop(stopModeManual)
 		return nil, err
 	} else {
+		// the streams are closed while stopped, so we reopen them and
+		// leave the video paused at the new position. otherwise, a
+		// later Play() would reopen the streams and start from 0
+		if c.state == Stopped {
+			err := c.noLockOpen()
+			if err != nil {
+				return nil, err
+			}
+			c.state = Paused
+			c.stateSignal.notify()
+		}
+
 		position = max(position, 0)
-		err := c.stream.Rewind(position)
+		served, err := c.noLockSeekPrefetched(position)
 		if err != nil {
 			return nil, err
 		}
-		c.lastReadFrame, err = c.internalReadVideoFrame()
-		if err != nil {
-			return c.lastReadFrame, err
+		if !served {
+			err = c.noLockRewind(position)
+			if err != nil {
+				return nil, err
+			}
+			c.lastReadFrame, err = c.internalReadVideoFrame()
+			if err != nil {
+				return c.lastReadFrame, err
+			}
 		}
 		c.referencePosition = position
-		c.referenceTime = time.Now()
-		return c.lastReadFrame, nil
+		c.referenceTime = nowFunc()
+		return c.lastReadFrame, c.noLockFillPrefetch()
+	}
+}
+
+func (*videoOnlyController) IsSeekable() bool {
+	return true
+}
+
+// Tries to serve a seek to the given position using the prefetched
+// frames. Returns false if the position falls outside the window.
+func (c *videoOnlyController) noLockSeekPrefetched(position time.Duration) (bool, error) {
+	if len(c.prefetched) == 0 || c.lastReadFrame == nil {
+		return false, nil
+	}
+
+	// the window goes from the current frame up to the end of the last prefetched one
+	start, err := c.lastReadFrame.PresentationOffset()
+	if err != nil {
+		return false, err
+	}
+	end, err := c.prefetched[len(c.prefetched)-1].PresentationOffset()
+	if err != nil {
+		return false, err
+	}
+	if position < start || position >= end+c.frameDuration {
+		return false, nil
 	}
+
+	// find the last frame starting at or before the target position
+	var consumed int
+	for consumed < len(c.prefetched) {
+		presOffset, err := c.prefetched[consumed].PresentationOffset()
+		if err != nil {
+			return false, err
+		}
+		if presOffset > position {
+			break
+		}
+		c.lastReadFrame = c.prefetched[consumed]
+		consumed += 1
+	}
+	c.noLockDropPrefetched(consumed)
+	return true, nil
+}
+
+// Returns the next video frame, taking it from the prefetched frames
+// if possible, or decoding it otherwise. A nil frame means the end of
+// the stream has been reached.
+func (c *videoOnlyController) noLockNextVideoFrame() (*reisen.VideoFrame, error) {
+	if len(c.prefetched) > 0 {
+		frame := c.prefetched[0]
+		c.noLockDropPrefetched(1)
+		return frame, nil
+	}
+	return c.internalReadVideoFrame()
+}
+
+// Decodes frames ahead of time until the prefetch depth is reached
+// or the end of the stream is found.
+func (c *videoOnlyController) noLockFillPrefetch() error {
+	for len(c.prefetched) < c.prefetchDepth && (c.decoder == nil || c.decoder.ready()) {
+		frame, err := c.internalReadVideoFrame()
+		if err != nil || frame == nil {
+			return err
+		}
+		c.prefetched = append(c.prefetched, frame)
+	}
+	return nil
+}
+
+func (c *videoOnlyController) noLockDropPrefetched(count int) {
+	kept := copy(c.prefetched, c.prefetched[count:])
+	clear(c.prefetched[kept:])
+	c.prefetched = c.prefetched[:kept]
+}
+
+// Rewinds the underlying stream, discarding any prefetched frames. The
+// background decoder, if any, is restarted unless the video is stopping.
+func (c *videoOnlyController) noLockRewind(position time.Duration) error {
+	c.noLockDropPrefetched(len(c.prefetched))
+	if c.decoder != nil {
+		c.decoder.stop()
+	}
+	c.lastDecodedOffset = position
+	err := c.stream.Rewind(position)
+	if err == nil && c.decoder != nil && c.state != Stopped {
+		c.decoder.start()
+	}
+	return err
+}
+
+// Returns whether the next frame can be read without blocking on I/O.
+// This is always true without background decoding.
+// preconditions: c.mutex is locked
+func (c *videoOnlyController) noLockNextFrameReady() bool {
+	return len(c.prefetched) > 0 || c.decoder == nil || c.decoder.ready()
+}
+
+// Returns nil if the decode error must be treated as the end of the
+// stream instead. See PlayerOptions.TolerateTruncatedEnd.
+func (c *videoOnlyController) noLockFilterDecodeError(err error) error {
+	if !c.tolerateTruncatedEnd || !isTruncatedEnd(c.lastDecodedOffset, c.duration) {
+		return err
+	}
+	c.logger.Printf("WARNING: decode error at %s, treating as end of video: %s", c.lastDecodedOffset, err)
+	return nil
 }
 
 func (c *videoOnlyController) GetLooping() bool {
@@ -269,25 +564,48 @@ func (c *videoOnlyController) SetLooping(loop bool) {
 	c.mutex.Unlock()
 }
 
+func (c *videoOnlyController) LoopCount() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.loopCount
+}
+
+func (c *videoOnlyController) GetLoopStart() time.Duration {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.loopStart
+}
+
+func (c *videoOnlyController) SetLoopStart(position time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.loopStart = clampLoopStart(position, c.duration, c.frameDuration)
+}
+
+func (c *videoOnlyController) SetEndBehavior(behavior EndBehavior) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.endBehavior = behavior
+}
+
 func (c *videoOnlyController) CurrentVideoFrame() (*reisen.VideoFrame, bool, error) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
 	if c.state == Stopped {
-		// TODO: in the case of end-of-video, we should probably allow to
-		//       keep reading... but the streams have already been closed.
-		//       It's tricky, unclear what's the best way to deal with it.
+		// on end-of-video, lastReadFrame is already the last frame of
+		// the video, see noLockExhaustVideoFrames()
 		return c.lastReadFrame, c.referencePosition == c.duration, nil
 	}
 
 	// get target position
-	now := time.Now()
+	now := nowFunc()
 	position, endedAsSideEffect, err := c.noLockPosition(now)
 	if err != nil {
 		return nil, false, err
 	}
 	if endedAsSideEffect {
-		// frames exhaustion would depend on noLockPosition behavior
+		// remaining frames were already exhausted by noLockEndOfVideo()
 		return c.lastReadFrame, true, nil
 	}
 
@@ -301,13 +619,43 @@ func (c *videoOnlyController) CurrentVideoFrame() (*reisen.VideoFrame, bool, err
 		prevPresOffset = presOffset
 	}
 
+	// seek instead of decoding all the frames if we are too far behind
+	catchUpSeek, threshold := c.catchUpStrategy == CatchUpSeekOnLargeGap, c.catchUpThreshold
+	if c.skipGap {
+		c.skipGap = false
+		if !catchUpSeek {
+			catchUpSeek, threshold = true, defaultCatchUpThreshold
+		}
+	}
+	if catchUpSeek && c.lastReadFrame != nil && !c.videoPendingLoop {
+		if position-presOffset > threshold {
+			err = c.noLockRewind(position)
+			if err != nil {
+				return nil, false, err
+			}
+		}
+	}
+
 	// read frames until we reach the target position
-	for presOffset+c.frameDuration < position || c.videoPendingLoop {
+	var advanced bool
+	for {
+		if !c.videoPendingLoop {
+			due, err := c.noLockNextFrameDue(presOffset, position)
+			if err != nil {
+				return nil, false, err
+			}
+			if !due {
+				break
+			}
+		}
 		if c.videoPendingLoop && presOffset < prevPresOffset {
 			c.videoPendingLoop = false
 		}
+		if !c.noLockNextFrameReady() {
+			break // still decoding in the background, keep the current frame
+		}
 
-		frame, err := c.internalReadVideoFrame()
+		frame, err := c.noLockNextVideoFrame()
 		if err != nil {
 			return nil, false, err
 		}
@@ -315,17 +663,18 @@ func (c *videoOnlyController) CurrentVideoFrame() (*reisen.VideoFrame, bool, err
 		// check whether the video is stopping
 		if frame == nil {
 			if c.looping {
-				err := c.stream.Rewind(0)
+				err := c.noLockRewind(c.loopStart)
 				if err != nil {
 					return nil, false, err
 				}
 				c.referenceTime = now
-				c.referencePosition = 0
+				c.referencePosition = c.loopStart
 				c.videoPendingLoop = true
+				c.loopCount += 1
 				return c.lastReadFrame, false, nil
 			}
 
-			err = c.noLockStop(stopModeEndOfVideo)
+			err = c.noLockEndOfVideo(now)
 			return c.lastReadFrame, true, err
 		}
 
@@ -335,18 +684,63 @@ func (c *videoOnlyController) CurrentVideoFrame() (*reisen.VideoFrame, bool, err
 		if err != nil {
 			return nil, false, err
 		}
+		if advanced { // previous frame is being replaced before being returned
+			c.droppedFrames += 1
+		}
 		c.lastReadFrame = frame
+		advanced = true
+	}
+
+	return c.lastReadFrame, false, c.noLockFillPrefetch()
+}
+
+// Returns whether the frame following the one at the given presentation
+// offset must already be presented at the given position.
+// preconditions: c.mutex is locked
+func (c *videoOnlyController) noLockNextFrameDue(presOffset, position time.Duration) (bool, error) {
+	if !c.variableFrameRate || c.lastReadFrame == nil {
+		return presOffset+c.frameDuration < position, nil
 	}
 
-	return c.lastReadFrame, false, nil
+	// frame durations vary, so peek at the next frame timestamp instead
+	if !c.noLockNextFrameReady() {
+		return false, nil
+	}
+	if len(c.prefetched) == 0 {
+		frame, err := c.internalReadVideoFrame()
+		if err != nil {
+			return false, err
+		}
+		if frame == nil { // last frame, its duration is unknown
+			return presOffset+c.frameDuration < position, nil
+		}
+		c.prefetched = append(c.prefetched, frame)
+	}
+	nextPresOffset, err := c.prefetched[0].PresentationOffset()
+	if err != nil {
+		return false, err
+	}
+	return nextPresOffset <= position, nil
 }
 
+// Returns the next video frame, from the background decoder if any, or
+// decoding it right away otherwise. A nil frame means the end of the stream.
+// preconditions: c.mutex is locked
 func (c *videoOnlyController) internalReadVideoFrame() (*reisen.VideoFrame, error) {
+	if c.decoder != nil {
+		return c.decoder.next()
+	}
+	return c.decodeVideoFrame()
+}
+
+// Decodes the next video frame from the stream. While the background
+// decoder is running, only its goroutine can call this.
+func (c *videoOnlyController) decodeVideoFrame() (*reisen.VideoFrame, error) {
 	// read packets until we come across the next video frame packet
 	for {
 		packet, packetFound, err := c.media.ReadPacket()
 		if err != nil {
-			return nil, err
+			return nil, c.noLockFilterDecodeError(err)
 		}
 
 		if !packetFound {
@@ -357,18 +751,64 @@ func (c *videoOnlyController) internalReadVideoFrame() (*reisen.VideoFrame, erro
 		}
 
 		if packet.Type() == reisen.StreamVideo && packet.StreamIndex() == c.stream.Index() {
-			frame, frameFound, err := c.stream.ReadVideoFrame()
+			frame, frameFound, err := c.decodeStats.readVideoFrame(c.stream)
 			if err != nil {
-				return nil, err
+				return nil, c.noLockFilterDecodeError(err)
 			}
 			_ = frameFound // frameFound can be true while frame is nil: that's a frame skip
 			if frame != nil {
+				c.lastDecodedOffset, _ = frame.PresentationOffset()
 				return frame, nil
 			}
 		}
 	}
 }
 
+func (c *videoOnlyController) SetCatchUpStrategy(strategy CatchUpStrategy, threshold time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.catchUpStrategy = strategy
+	c.catchUpThreshold = threshold
+}
+
+// Makes the next frame request seek instead of decoding all the frames
+// if it's too far behind, see Player.SetVisible().
+func (c *videoOnlyController) skipNextGap() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.skipGap = true
+}
+
+// Overrides the nominal frame duration, see Player.SetFrameRateOverride().
+func (c *videoOnlyController) setFrameDuration(frameDuration time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.frameDuration = frameDuration
+}
+
+// Copies the catch up configuration of the given controller. Used for
+// seamless looping, see Player.SetSeamlessLoop().
+func (c *videoOnlyController) copyConfigFrom(src *videoOnlyController) {
+	src.mutex.Lock()
+	strategy, threshold := src.catchUpStrategy, src.catchUpThreshold
+	src.mutex.Unlock()
+	c.SetCatchUpStrategy(strategy, threshold)
+}
+
+func (c *videoOnlyController) DroppedFrameCount() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.droppedFrames
+}
+
+func (c *videoOnlyController) DecodeStats() DecodeStats {
+	return c.decodeStats.stats()
+}
+
+// videos without audio are only decoded during method calls, so all
+// errors are returned directly and the handler is never called
+func (*videoOnlyController) SetErrorHandler(func(error)) {}
+
 func (*videoOnlyController) Error() error {
 	return nil
 }
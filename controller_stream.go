@@ -8,7 +8,7 @@ import (
 	"github.com/erparts/reisen"
 )
 
-var _ videoController = (*streamVideoController)(nil)
+var _ VideoController = (*streamVideoController)(nil)
 
 // Tunables for live playback behavior.
 const (
@@ -17,14 +17,25 @@ const (
 	// decodeErrSleepLive is the backoff used when the decoder encounters
 	// transient errors or starvation on a live source.
 	decodeErrSleepLive = 10 * time.Millisecond
+	// decodedQueueSize is the capacity of the decoded frames queue.
+	decodedQueueSize = 64
 )
 
+// decodedFrame is a frame pushed by the decoding goroutine together with its
+// PTS. The scheduler only deals with these, so frames can be fed to it without
+// a real decoder (e.g. in tests).
+type decodedFrame struct {
+	frame *reisen.VideoFrame
+	pts   time.Duration
+}
+
 // streamVideoController manages live-only playback using PTS-based scheduling.
 //
 // Design overview
 //
 //   - Decoding: a dedicated goroutine reads packets/frames from the reisen
-//     Media/VideoStream and pushes decoded frames into a buffered channel.
+//     Media/VideoStream and pushes decoded frames and their PTS into a
+//     buffered channel. Frames without a PTS are dropped.
 //   - Scheduling: a second goroutine consumes decoded frames and delays their
 //     presentation until the wall-clock time corresponding to each frame’s PTS.
 //   - Timebase: when the first frame is observed, its PTS is recorded as ptsBase
@@ -51,33 +62,53 @@ type streamVideoController struct {
 	referencePosition time.Duration
 
 	lastReadFrame *reisen.VideoFrame
+	frameReturned bool // whether lastReadFrame has been returned by CurrentVideoFrame()
+	droppedFrames int
 
 	havePTSBase bool
 	ptsBase     time.Duration
 	wallBase    time.Time
 	jitter      time.Duration
+	dropPolicy  DropPolicy
+
+	// frames buffered before the first one is released, see StreamOptions
+	preRollFrames   int
+	preRollDuration time.Duration
+
+	// notified on each state change, see Player.WaitForState()
+	stateSignal stateSignal
 
-	stopCh    chan struct{}
-	wg        sync.WaitGroup
-	decodedCh chan *reisen.VideoFrame
-	errCh     chan error
+	stopCh      chan struct{}
+	wg          sync.WaitGroup
+	decodedCh   chan decodedFrame
+	errReporter errorReporter
+	decodeStats decodeStatsCollector
 }
 
 // newStreamVideoController constructs a controller for a live video stream.
 // The provided media and video stream must be non-nil and unopened. The
 // controller is created in Stopped state; call Play() to start.
-func newStreamVideoController(media *reisen.Media, s *reisen.VideoStream) (videoController, error) {
+func newStreamVideoController(media *reisen.Media, s *reisen.VideoStream, opts StreamOptions) (VideoController, error) {
 	if media == nil || s == nil {
 		return nil, fmt.Errorf("nil media or video stream")
 	}
 	return &streamVideoController{
-		media:  media,
-		stream: s,
-		state:  Stopped,
-		jitter: defaultJitter,
+		media:      media,
+		stream:     s,
+		state:      Stopped,
+		jitter:     defaultJitter,
+		dropPolicy: opts.DropPolicy,
+
+		preRollFrames:   opts.PreRollFrames,
+		preRollDuration: opts.PreRollDuration,
 	}, nil
 }
 
+// stateChanged returns a channel that is closed on the next state change.
+func (c *streamVideoController) stateChanged() <-chan struct{} {
+	return c.stateSignal.changed()
+}
+
 // Play opens the decoder/stream (if needed) and starts the decode and schedule
 // goroutines. If already Playing, Play is a no-op. On first Play after Stop,
 // PTS and reference clocks are reset.
@@ -109,8 +140,7 @@ func (c *streamVideoController) Play() error {
 
 		// Start background pipelines.
 		c.stopCh = make(chan struct{})
-		c.decodedCh = make(chan *reisen.VideoFrame, 64)
-		c.errCh = make(chan error, 1)
+		c.decodedCh = make(chan decodedFrame, decodedQueueSize)
 
 		c.wg.Add(1)
 		go c.decodeLoop()
@@ -119,24 +149,44 @@ func (c *streamVideoController) Play() error {
 		go c.scheduleLoop()
 	}
 
-	c.referenceTime = time.Now()
+	c.referenceTime = nowFunc()
 	c.state = Playing
+	c.stateSignal.notify()
 	return nil
 }
 
+// Restart is unsupported for live streams and returns an error.
+func (c *streamVideoController) Restart() error {
+	return fmt.Errorf("cannot restart a live stream")
+}
+
+// Prime is unsupported for live streams and returns an error.
+func (c *streamVideoController) Prime() (*reisen.VideoFrame, error) {
+	return nil, fmt.Errorf("cannot prime a live stream")
+}
+
 // State returns the current playback state. It also updates the internal logical
 // clock using the current wall-clock to keep Position() fresh for UI consumers.
 func (c *streamVideoController) State() (PlaybackState, error) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
-	_, _, _ = c.noLockPosition(time.Now())
+	_, _, _ = c.noLockPosition(nowFunc())
 	return c.state, nil
 }
 
+// PeekState returns the current state without any side effects.
+func (c *streamVideoController) PeekState() PlaybackState {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.state
+}
+
 // Pause transitions from Playing to Paused and captures the current logical
 // position based on wall-clock. Pausing does not stop decoding; frames continue
-// to be processed but the scheduler will not delay for Paused state here—UI
-// should gate rendering on c.state if needed.
+// to be drained from decodedCh so the decoder never blocks, but the scheduler
+// discards them instead of publishing them, so CurrentVideoFrame() keeps
+// returning the frame that was visible when pausing. Since the source is live,
+// resuming continues from the live edge rather than from the paused frame.
 func (c *streamVideoController) Pause() error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
@@ -144,9 +194,10 @@ func (c *streamVideoController) Pause() error {
 	if c.state != Playing {
 		return nil
 	}
-	now := time.Now()
+	now := nowFunc()
 	pos, _, _ := c.noLockPosition(now)
 	c.state = Paused
+	c.stateSignal.notify()
 	c.referenceTime = now
 	c.referencePosition = pos
 	return nil
@@ -191,10 +242,6 @@ func (c *streamVideoController) noLockStop(_ stopMode) error {
 		close(c.decodedCh)
 		c.decodedCh = nil
 	}
-	if c.errCh != nil {
-		close(c.errCh)
-		c.errCh = nil
-	}
 
 	c.referencePosition = 0
 	c.lastReadFrame = nil
@@ -203,6 +250,7 @@ func (c *streamVideoController) noLockStop(_ stopMode) error {
 	}
 
 	c.state = Stopped
+	c.stateSignal.notify()
 	c.referenceTime = time.Time{}
 
 	// In live mode there is no rewind/seekable resource—just close.
@@ -218,7 +266,7 @@ func (c *streamVideoController) noLockStop(_ stopMode) error {
 func (c *streamVideoController) Position() (time.Duration, error) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
-	pos, _, err := c.noLockPosition(time.Now())
+	pos, _, err := c.noLockPosition(nowFunc())
 	return pos, err
 }
 
@@ -232,6 +280,11 @@ func (c *streamVideoController) Seek(_ time.Duration) (*reisen.VideoFrame, error
 	return nil, fmt.Errorf("cannot seek in live stream")
 }
 
+// IsSeekable always returns false for live streams.
+func (_ *streamVideoController) IsSeekable() bool {
+	return false
+}
+
 // GetLooping always returns false for live streams.
 func (_ *streamVideoController) GetLooping() bool {
 	return false
@@ -240,15 +293,45 @@ func (_ *streamVideoController) GetLooping() bool {
 // SetLooping is a no-op for live streams.
 func (_ *streamVideoController) SetLooping(_ bool) {}
 
+// GetLoopStart always returns 0 for live streams.
+func (_ *streamVideoController) GetLoopStart() time.Duration {
+	return 0
+}
+
+// SetLoopStart is a no-op for live streams.
+func (_ *streamVideoController) SetLoopStart(_ time.Duration) {}
+
+// LoopCount always returns 0 for live streams.
+func (_ *streamVideoController) LoopCount() int {
+	return 0
+}
+
+// SetEndBehavior is a no-op for live streams, as they don't have an end.
+func (_ *streamVideoController) SetEndBehavior(_ EndBehavior) {}
+
 // CurrentVideoFrame returns the most recently scheduled frame. The boolean
 // return value is unused here and remains false for compatibility with other
 // controllers that might include “new frame available” semantics.
 func (c *streamVideoController) CurrentVideoFrame() (*reisen.VideoFrame, bool, error) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
+	c.frameReturned = true
 	return c.lastReadFrame, false, nil
 }
 
+// DroppedFrameCount returns the amount of frames released by the scheduler
+// that were replaced by newer frames before CurrentVideoFrame() returned them.
+func (c *streamVideoController) DroppedFrameCount() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.droppedFrames
+}
+
+// DecodeStats returns the timing statistics of the decoding goroutine.
+func (c *streamVideoController) DecodeStats() DecodeStats {
+	return c.decodeStats.stats()
+}
+
 // noLockPosition computes the logical position at time now without side effects
 // on external state. If Playing, it advances from referenceTime by wall time;
 // otherwise it returns the last captured referencePosition.
@@ -276,13 +359,8 @@ func (c *streamVideoController) decodeLoop() {
 
 		packet, ok, err := c.media.ReadPacket()
 		if err != nil {
-			// Report error if a consumer is listening, but do not block.
-			select {
-			case <-c.stopCh:
-				return
-			case c.errCh <- err:
-			default:
-			}
+			// Report error if a handler is set, and keep going.
+			c.errReporter.report(err)
 			time.Sleep(decodeErrSleepLive)
 			continue
 		}
@@ -295,78 +373,194 @@ func (c *streamVideoController) decodeLoop() {
 			continue
 		}
 
-		frame, got, err := c.stream.ReadVideoFrame()
+		frame, got, err := c.decodeStats.readVideoFrame(c.stream)
 		if err != nil {
 			// Non-fatal on live inputs: report and keep going.
-			select {
-			case <-c.stopCh:
-				return
-			case c.errCh <- err:
-			default:
-			}
+			c.errReporter.report(err)
 			continue
 		}
 		if !got || frame == nil {
 			continue
 		}
+		pts, err := frame.PresentationOffset()
+		if err != nil {
+			// If PTS is unavailable, drop the frame; live sync requires PTS.
+			continue
+		}
 
+		if !c.enqueueFrame(decodedFrame{frame: frame, pts: pts}) {
+			return
+		}
+	}
+}
+
+// enqueueFrame sends a decoded frame to the scheduler following the configured
+// DropPolicy. It returns false if the controller is stopping.
+func (c *streamVideoController) enqueueFrame(frame decodedFrame) bool {
+	switch c.dropPolicy {
+	case DropPolicyDropNewest:
 		select {
 		case <-c.stopCh:
-			return
+			return false
+		case c.decodedCh <- frame:
+		default:
+			c.countQueueDrop()
+		}
+		return true
+	case DropPolicyDropOldest:
+		for {
+			select {
+			case <-c.stopCh:
+				return false
+			case c.decodedCh <- frame:
+				return true
+			default:
+				// queue full: discard the oldest frame, unless the
+				// scheduler took it already, and try again
+				select {
+				case <-c.decodedCh:
+					c.countQueueDrop()
+				default:
+				}
+			}
+		}
+	default: // DropPolicyBlock
+		select {
+		case <-c.stopCh:
+			return false
 		case c.decodedCh <- frame:
+			return true
 		}
 	}
 }
 
+func (c *streamVideoController) countQueueDrop() {
+	c.mutex.Lock()
+	c.droppedFrames += 1
+	c.mutex.Unlock()
+}
+
 // scheduleLoop aligns frames to wall-clock based on PTS. For the first frame,
 // it captures ptsBase and wallBase. For each subsequent frame, it computes the
 // due time as wallBase + (PTS - ptsBase). If Playing and due is sufficiently
 // in the future (beyond jitter), it sleeps until due; otherwise it publishes
 // immediately. After publishing, it updates the logical reference clock.
+// While Paused, frames are consumed but discarded without being published.
+// If a pre-roll is configured, frames are buffered before the first one is
+// scheduled, see preRoll().
 func (c *streamVideoController) scheduleLoop() {
 	defer c.wg.Done()
 
+	pending, ok := c.preRoll()
+	if !ok {
+		return
+	}
+	for _, f := range pending {
+		if !c.scheduleFrame(f) {
+			return
+		}
+	}
+
 	for {
 		select {
 		case <-c.stopCh:
 			return
 		case f, ok := <-c.decodedCh:
-			if !ok {
+			if !ok || !c.scheduleFrame(f) {
 				return
 			}
-			pts, err := f.PresentationOffset()
-			if err != nil {
-				// If PTS is unavailable, drop the frame; live sync requires PTS.
-				continue
-			}
+		}
+	}
+}
 
-			c.mutex.Lock()
-			if !c.havePTSBase {
-				c.ptsBase = pts
-				c.wallBase = time.Now()
-				c.havePTSBase = true
+// preRoll collects decoded frames until the configured pre-roll frame count
+// or duration is reached, so the first frame is only released once there's
+// a cushion of frames ready to absorb jitter. Without pre-roll, it returns
+// immediately. It returns false if the controller is stopping.
+func (c *streamVideoController) preRoll() ([]decodedFrame, bool) {
+	if c.preRollFrames <= 0 && c.preRollDuration <= 0 {
+		return nil, true
+	}
+
+	var pending []decodedFrame
+	var firstPTS time.Duration
+	for {
+		select {
+		case <-c.stopCh:
+			return nil, false
+		case f, ok := <-c.decodedCh:
+			if !ok {
+				return nil, false
 			}
-			due := c.wallBase.Add(pts - c.ptsBase)
-			j := c.jitter
-			st := c.state
-			c.mutex.Unlock()
+			if len(pending) == 0 {
+				firstPTS = f.pts
+			}
+			pending = append(pending, f)
 
-			now := time.Now()
-			if st == Playing && due.After(now.Add(j)) {
-				select {
-				case <-c.stopCh:
-					return
-				case <-time.After(due.Sub(now)):
-				}
+			// both conditions must be met if both are configured
+			framesReady := len(pending) >= c.preRollFrames
+			durationReady := f.pts-firstPTS >= c.preRollDuration
+			if (framesReady && durationReady) || len(pending) >= decodedQueueSize {
+				return pending, true
 			}
+		}
+	}
+}
+
+// scheduleFrame waits until the given frame is due and publishes it. It
+// returns false if the controller is stopping.
+func (c *streamVideoController) scheduleFrame(f decodedFrame) bool {
+	c.mutex.Lock()
+	if !c.havePTSBase {
+		c.ptsBase = f.pts
+		c.wallBase = nowFunc()
+		c.havePTSBase = true
+	}
+	due := c.wallBase.Add(f.pts - c.ptsBase)
+	j := c.jitter
+	st := c.state
+	c.mutex.Unlock()
 
-			c.mutex.Lock()
-			c.lastReadFrame = f
-			c.referencePosition = pts - c.ptsBase
-			c.referenceTime = time.Now()
-			c.mutex.Unlock()
+	now := nowFunc()
+	if st == Playing && due.After(now.Add(j)) {
+		select {
+		case <-c.stopCh:
+			return false
+		case <-time.After(due.Sub(now)):
 		}
 	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.noLockPublishFrame(f)
+	return true
+}
+
+// noLockPublishFrame makes the given frame the one returned by
+// CurrentVideoFrame() and moves the logical clock to its PTS, unless
+// the controller is not playing.
+// preconditions: c.mutex is locked
+func (c *streamVideoController) noLockPublishFrame(f decodedFrame) {
+	if c.state != Playing {
+		// paused (possibly while we were waiting): keep the
+		// current frame and clock frozen, drop this frame
+		return
+	}
+	if c.lastReadFrame != nil && !c.frameReturned {
+		c.droppedFrames += 1
+	}
+	c.lastReadFrame = f.frame
+	c.frameReturned = false
+	c.referencePosition = f.pts - c.ptsBase
+	c.referenceTime = nowFunc()
+}
+
+// SetErrorHandler sets a function to be called with the errors found by the
+// decoding goroutine. These errors are not fatal for live streams, so playback
+// continues after reporting them. The handler is called from the decoding
+// goroutine, so slow handlers will delay decoding.
+func (c *streamVideoController) SetErrorHandler(handler func(error)) {
+	c.errReporter.setHandler(handler)
 }
 
 func (*streamVideoController) Error() error {
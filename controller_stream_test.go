@@ -0,0 +1,145 @@
+package avebi
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/erparts/reisen"
+)
+
+// fakeClock replaces nowFunc for the duration of a test, so time only
+// passes when the test calls advance().
+type fakeClock struct {
+	mutex sync.Mutex
+	t     time.Time
+}
+
+func newFakeClock(t *testing.T) *fakeClock {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	prev := nowFunc
+	nowFunc = clock.now
+	t.Cleanup(func() { nowFunc = prev })
+	return clock
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mutex.Lock()
+	c.t = c.t.Add(d)
+	c.mutex.Unlock()
+}
+
+const streamTestFrameDuration = 40 * time.Millisecond
+
+func newStreamTestController(clock *fakeClock) *streamVideoController {
+	return &streamVideoController{
+		state:         Playing,
+		jitter:        defaultJitter,
+		referenceTime: clock.now(),
+	}
+}
+
+func TestStreamFrameStableWhilePaused(t *testing.T) {
+	clock := newFakeClock(t)
+	c := newStreamTestController(clock)
+
+	// frames are fed once they are due, so the scheduler never sleeps
+	var pts time.Duration
+	feed := func() *reisen.VideoFrame {
+		frame := &reisen.VideoFrame{}
+		if !c.scheduleFrame(decodedFrame{frame: frame, pts: pts}) {
+			t.Fatal("scheduleFrame() reported the controller as stopping")
+		}
+		pts += streamTestFrameDuration
+		clock.advance(streamTestFrameDuration)
+		return frame
+	}
+
+	feed()
+	visible := feed()
+	if err := c.Pause(); err != nil {
+		t.Fatalf("Pause() failed: %v", err)
+	}
+	pausedPos, err := c.Position()
+	if err != nil {
+		t.Fatalf("Position() failed: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		feed()
+		frame, _, err := c.CurrentVideoFrame()
+		if err != nil {
+			t.Fatalf("CurrentVideoFrame() failed: %v", err)
+		}
+		if frame != visible {
+			t.Fatalf("frame %d changed the visible frame while paused", i)
+		}
+		pos, err := c.Position()
+		if err != nil {
+			t.Fatalf("Position() failed: %v", err)
+		}
+		if pos != pausedPos {
+			t.Fatalf("position moved from %v to %v while paused", pausedPos, pos)
+		}
+	}
+
+	// resuming continues from the live edge
+	if err := c.Play(); err != nil {
+		t.Fatalf("Play() failed: %v", err)
+	}
+	livePTS := pts
+	live := feed()
+	frame, _, err := c.CurrentVideoFrame()
+	if err != nil {
+		t.Fatalf("CurrentVideoFrame() failed: %v", err)
+	}
+	if frame != live {
+		t.Fatal("the first frame after resuming was not published")
+	}
+	if pos, _ := c.Position(); pos != livePTS+streamTestFrameDuration {
+		t.Fatalf("expected position %v after resuming, got %v", livePTS+streamTestFrameDuration, pos)
+	}
+	if dropped := c.DroppedFrameCount(); dropped != 1 {
+		// only the first frame, replaced before being returned
+		t.Fatalf("expected 1 dropped frame, got %d", dropped)
+	}
+}
+
+func TestStreamDrainsWhilePaused(t *testing.T) {
+	clock := newFakeClock(t)
+	c := newStreamTestController(clock)
+	c.state = Paused
+	c.dropPolicy = DropPolicyBlock
+	c.stopCh = make(chan struct{})
+	c.decodedCh = make(chan decodedFrame, decodedQueueSize)
+	c.wg.Add(1)
+	go c.scheduleLoop()
+
+	// with DropPolicyBlock the decoder would get stuck if the
+	// scheduler stopped consuming frames while paused
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 2*decodedQueueSize; i++ {
+			pts := time.Duration(i) * streamTestFrameDuration
+			c.enqueueFrame(decodedFrame{frame: &reisen.VideoFrame{}, pts: pts})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("enqueueFrame() blocked while paused")
+	}
+
+	close(c.stopCh)
+	c.wg.Wait()
+	if frame, _, _ := c.CurrentVideoFrame(); frame != nil {
+		t.Fatal("a frame was published while paused")
+	}
+}
@@ -1,6 +1,8 @@
 package avebi
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"math"
 	"sync"
@@ -22,7 +24,27 @@ import (
 
 // player buffer size of 40ms should be ok on desktops. 70ms should be
 // ok on wasm/web. for microcontrollers, you might have to experiment.
-const playerBufferSize time.Duration = 200 * time.Millisecond
+// this is only the default, see PlayerOptions.AudioBufferSize
+const defaultPlayerBufferSize time.Duration = 200 * time.Millisecond
+
+// video frames decoded while reading audio ahead are kept until the
+// video catches up. this is only the default limit for those frames,
+// see PlayerOptions.MaxLeftoverVideoFrames
+const defaultMaxLeftoverVideoFrames = 120
+
+// maximum packets demuxed per Read() call to bound the work done while
+// holding the mutex. this is only the default, see PlayerOptions.MaxPacketsPerRead
+const defaultMaxPacketsPerRead = 256
+
+// maximum difference between the expected and actual presentation offset
+// of consecutive audio frames for them to be considered contiguous in the
+// history used for buffered seeks, see PlayerOptions.SeekBufferDuration
+const seekHistoryGapTolerance = 5 * time.Millisecond
+
+// audio kept on top of the audio player buffer size even without buffered
+// seeks, so the data handed to ebitengine but not played yet can be served
+// again when resuming after a pause. see noLockRestorePausedAudio()
+const pauseHistoryMargin = 100 * time.Millisecond
 
 const panicOnPartialSampleReads = false // set to true if you want to ensure ebitengine doesn't ask you for partial samples
 
@@ -30,61 +52,150 @@ const panicOnPartialSampleReads = false // set to true if you want to ensure ebi
 // is recommended. most comments there are not repeated here, but do
 // typically still apply
 
-var _ videoController = (*videoWithAudioController)(nil)
+var _ VideoController = (*videoWithAudioController)(nil)
+
+// audioOutput is the part of *audio.Player used by the controller.
+type audioOutput interface {
+	Play()
+	Pause()
+	Close() error
+	Position() time.Duration
+	SetVolume(volume float64)
+	SetBufferSize(bufferSize time.Duration)
+}
+
+// newAudioOutput creates the audio players for the controllers on the current
+// audio context. Like nowFunc, tests can replace it to run the controllers
+// without an actual audio context.
+var newAudioOutput = func(src io.Reader) (audioOutput, error) {
+	player, err := audio.CurrentContext().NewPlayer(src)
+	if err != nil {
+		return nil, err
+	}
+	return player, nil
+}
+
+// NOTICE: the video stream can be nil for audio-only media (see
+// PlayerOptions.AllowAudioOnly). in that case, no video frames are
+// ever decoded and CurrentVideoFrame() always returns nil frames
 
 type videoWithAudioController struct {
-	// mutex and underlying reisen objects
-	mutex sync.RWMutex
-	media *reisen.Media
-	video *reisen.VideoStream
-	audio *reisen.AudioStream
+	// mutex and underlying reisen objects. with background decoding,
+	// Read() releases mutex while reading packets and decoding, so the
+	// methods accessing the media also lock decodeMutex, see lockMedia()
+	mutex       sync.RWMutex
+	decodeMutex sync.Mutex
+	mediaLocked bool // whether the current mutex holder also holds decodeMutex
+	media       *reisen.Media
+	video       *reisen.VideoStream
+	audio       *reisen.AudioStream
 
 	// static data
-	duration      time.Duration // complete video duration
-	frameDuration time.Duration
+	duration           time.Duration // complete video duration
+	frameDuration      time.Duration
+	decodeWidth        int // see PlayerOptions.DecodeScale
+	decodeHeight       int
+	backgroundDecoding bool // see PlayerOptions.BackgroundDecoding
 
 	// state variables
 	looping          bool
+	loopStart        time.Duration
+	endBehavior      EndBehavior
+	avSyncOffset     time.Duration // positive values delay video
 	videoPendingLoop bool
+	loopCount        int // total loops, never reset
 	muted            bool
+	muteFade         time.Duration // see SetMuteFadeDuration()
+	muteRamp         gainRamp
 	state            PlaybackState
 	volume           float64
+	pan              float64
+	stereoSwap       bool
+	bassGain         float64 // in dB
+	trebleGain       float64 // in dB
+	bassFilter       biquadFilter
+	trebleFilter     biquadFilter
+	audioProcessor   AudioProcessor
+	audioSink        io.Writer // see SetAudioSink()
+	sampleBuffer     []int16   // scratch buffer for audio processing
+	levelMeter       levelMeter
+	spectrum         spectrumAnalyzer // only enabled after first use
 	lastReadFrame    *reisen.VideoFrame
 	leftoverVideo    []*reisen.VideoFrame
+	maxLeftoverVideo int // negative means unlimited
+	droppedFrames    int
+	decodeStats      decodeStatsCollector
 
 	// audio-specific internal management
-	audioPlayer                 *audio.Player
+	audioPlayer                 audioOutput
+	audioBufferSize             time.Duration
+	sampleRate                  int             // of the audio context, also used for all the decoded audio
+	resampler                   *audioResampler // nil if the media already uses the context sample rate
+	maxPacketsPerRead           int             // negative means unlimited
 	leftoverAudio               []byte
+	leftoverAudioOffset         time.Duration // presentation offset of the first leftover byte
 	firstAudioFrameOffsetOnPlay time.Duration
 	needsFirstAudioFrameOffset  bool
 	staticPosition              time.Duration // set manually and used when video is paused or stopped
+	monotonicPosition           bool          // if true, positionFloor is applied during continuous playback
+	positionFloor               time.Duration // highest position reported since the last discontinuity
+	positionSource              PositionSource
+
+	// truncated files tolerance, see PlayerOptions.TolerateTruncatedEnd
+	tolerateTruncatedEnd bool
+	lastDecodedOffset    time.Duration
+
+	// notified on each state change, see Player.WaitForState()
+	stateSignal stateSignal
+
+	// recently decoded data kept for buffered seeks, see PlayerOptions.SeekBufferDuration.
+	// a short audio history is always kept for pauses, see noLockRestorePausedAudio()
+	seekBufferDuration time.Duration
+	audioHistory       []byte
+	audioHistoryEnd    time.Duration // presentation offset right after the last history byte
+	videoHistory       []*reisen.VideoFrame
 
 	// last fatal decode/playback error (if any). this is kept internal and
 	// never propagated directly to ebitengine; Read only returns nil or io.EOF.
-	decodeErr error
+	// the error is also sent to the error reporter handler, if any
+	decodeErr   error
+	errReporter errorReporter
+
+	// shared with the player, see Player.SetLogPrefix()
+	logger *playerLogger
 }
 
-func newVideoWithAudioController(media *reisen.Media, videoStream *reisen.VideoStream, audioStream *reisen.AudioStream) (videoController, error) {
+func newVideoWithAudioController(media *reisen.Media, videoStream *reisen.VideoStream, audioStream *reisen.AudioStream, opts PlayerOptions, logger *playerLogger) (VideoController, error) {
 	// basic safety assertions and checks
-	if media == nil || videoStream == nil || audioStream == nil {
-		panic("nil media or video or audio stream")
+	if media == nil || audioStream == nil {
+		panic("nil media or audio stream")
 	}
 	audioSampleRate := audioStream.SampleRate()
 	audioContext := audio.CurrentContext()
 	if audioContext == nil {
 		return nil, ErrNilAudioContext
 	}
-	if audioContext.SampleRate() != audioSampleRate {
-		pkgLogger.Printf("WARNING: context sample rate = %d, video audio sample rate = %d\n", audioContext.SampleRate(), audioSampleRate)
+	if audioSampleRate <= 0 {
+		logger.Printf("WARNING: invalid video audio sample rate = %d\n", audioSampleRate)
 		return nil, ErrBadSampleRate
 	}
+	var resampler *audioResampler
+	if audioContext.SampleRate() != audioSampleRate {
+		// common on browsers, which impose their own sample rate
+		resampler = newAudioResampler(audioSampleRate, audioContext.SampleRate())
+	}
 
 	// get media duration
-	frNum, frDenom := videoStream.FrameRate()
-	frameDuration := (time.Second * time.Duration(frDenom)) / time.Duration(frNum)
-	videoDuration, err := videoStream.Duration()
-	if err != nil {
-		return nil, err
+	var frameDuration, videoDuration time.Duration
+	var decodeWidth, decodeHeight int
+	var err error
+	if videoStream != nil {
+		frameDuration = nominalFrameDuration(videoStream)
+		videoDuration, err = videoStream.Duration()
+		if err != nil {
+			return nil, err
+		}
+		decodeWidth, decodeHeight = decodeResolution(videoStream, opts)
 	}
 	audioDuration, err := audioStream.Duration()
 	if err != nil {
@@ -94,7 +205,31 @@ func newVideoWithAudioController(media *reisen.Media, videoStream *reisen.VideoS
 	// TODO: video and audio durations can indeed be different, and we definitely
 	// need to account for it with the internal clocks
 
-	return &videoWithAudioController{
+	audioBufferSize := defaultPlayerBufferSize
+	if opts.AudioBufferSize > 0 {
+		audioBufferSize = opts.AudioBufferSize
+	}
+	maxLeftoverVideo := defaultMaxLeftoverVideoFrames
+	if opts.MaxLeftoverVideoFrames != 0 {
+		maxLeftoverVideo = opts.MaxLeftoverVideoFrames
+	}
+	maxPacketsPerRead := defaultMaxPacketsPerRead
+	if opts.MaxPacketsPerRead != 0 {
+		maxPacketsPerRead = opts.MaxPacketsPerRead
+	}
+	volume := 1.0
+	if opts.InitialVolume != nil && !math.IsNaN(*opts.InitialVolume) {
+		volume = min(max(*opts.InitialVolume, 0.0), 1.0)
+		if volume != *opts.InitialVolume {
+			logger.Printf("WARNING: initial volume %v is outside [0, 1]; clamping to %v\n", *opts.InitialVolume, volume)
+		}
+	}
+	muteGain := 1.0
+	if opts.InitialMuted {
+		muteGain = 0.0
+	}
+
+	controller := &videoWithAudioController{
 		// underlying reisen objects
 		media: media,
 		video: videoStream,
@@ -103,15 +238,31 @@ func newVideoWithAudioController(media *reisen.Media, videoStream *reisen.VideoS
 		// static values
 		duration:      duration,
 		frameDuration: frameDuration,
+		decodeWidth:   decodeWidth,
+		decodeHeight:  decodeHeight,
+
+		backgroundDecoding: opts.BackgroundDecoding,
 
 		// state variables
-		state:         Stopped,
-		volume:        1.0,
-		leftoverVideo: make([]*reisen.VideoFrame, 0, 8),
+		state:            Stopped,
+		volume:           volume,
+		muted:            opts.InitialMuted,
+		muteRamp:         gainRamp{gain: muteGain},
+		leftoverVideo:    make([]*reisen.VideoFrame, 0, 8),
+		maxLeftoverVideo: maxLeftoverVideo,
 
 		// audio-related internal state
-		leftoverAudio: make([]byte, 0, 1024),
-	}, err
+		sampleRate:           audioContext.SampleRate(),
+		resampler:            resampler,
+		leftoverAudio:        make([]byte, 0, 1024),
+		audioBufferSize:      audioBufferSize,
+		maxPacketsPerRead:    maxPacketsPerRead,
+		tolerateTruncatedEnd: opts.TolerateTruncatedEnd,
+		seekBufferDuration:   max(opts.SeekBufferDuration, 0),
+		logger:               logger,
+	}
+	masterVolume.register(controller)
+	return controller, nil
 }
 
 // --- audio-specific methods ---
@@ -125,9 +276,9 @@ func (c *videoWithAudioController) GetVolume() float64 {
 func (c *videoWithAudioController) SetVolume(volume float64) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
-	c.volume = volume
+	c.volume = min(max(volume, 0.0), 1.0) // ebitengine panics outside [0, 1]
 	if c.audioPlayer != nil {
-		c.audioPlayer.SetVolume(volume)
+		c.audioPlayer.SetVolume(c.getEffectiveVolume())
 	}
 }
 
@@ -135,6 +286,27 @@ func (c *videoWithAudioController) SetMuted(muted bool) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	c.muted = muted
+	if c.audioPlayer != nil {
+		c.audioPlayer.SetVolume(c.getEffectiveVolume())
+	}
+}
+
+// Sets the duration of the fade applied when muting or unmuting. With a
+// non-zero duration, muting is applied as a gain ramp on the audio data
+// served to ebitengine instead of through the player volume.
+func (c *videoWithAudioController) SetMuteFadeDuration(fade time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.muteFade = max(fade, 0)
+	if c.audioPlayer != nil {
+		c.audioPlayer.SetVolume(c.getEffectiveVolume())
+	}
+}
+
+func (c *videoWithAudioController) GetMuteFadeDuration() time.Duration {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.muteFade
 }
 
 func (c *videoWithAudioController) GetMuted() bool {
@@ -143,28 +315,258 @@ func (c *videoWithAudioController) GetMuted() bool {
 	return c.muted
 }
 
+func (c *videoWithAudioController) GetPan() float64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.pan
+}
+
+func (c *videoWithAudioController) SetPan(pan float64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.pan = min(max(pan, -1.0), 1.0)
+}
+
+func (c *videoWithAudioController) GetStereoSwap() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.stereoSwap
+}
+
+func (c *videoWithAudioController) SetStereoSwap(swap bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.stereoSwap = swap
+}
+
+func (c *videoWithAudioController) SetAudioProcessor(processor AudioProcessor) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.audioProcessor = processor
+}
+
+// Sets a writer that receives a copy of the audio data served to
+// ebitengine. Write errors are reported to the error handler, and the
+// sink is removed after them.
+func (c *videoWithAudioController) SetAudioSink(sink io.Writer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.audioSink = sink
+}
+
+func (c *videoWithAudioController) GetBassGain() float64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.bassGain
+}
+
+func (c *videoWithAudioController) SetBassGain(gainDB float64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.bassGain = min(max(gainDB, -maxShelfGainDB), maxShelfGainDB)
+	c.bassFilter.setShelf(lowShelf, c.sampleRate, bassShelfFrequency, c.bassGain)
+}
+
+func (c *videoWithAudioController) GetTrebleGain() float64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.trebleGain
+}
+
+func (c *videoWithAudioController) SetTrebleGain(gainDB float64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.trebleGain = min(max(gainDB, -maxShelfGainDB), maxShelfGainDB)
+	c.trebleFilter.setShelf(highShelf, c.sampleRate, trebleShelfFrequency, c.trebleGain)
+}
+
+func (c *videoWithAudioController) SetMonotonicPosition(monotonic bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.monotonicPosition = monotonic
+	c.positionFloor = 0
+}
+
+func (c *videoWithAudioController) SetPositionSource(source PositionSource) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.positionSource = source
+}
+
+func (c *videoWithAudioController) GetPositionSource() PositionSource {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.positionSource
+}
+
+func (c *videoWithAudioController) GetMonotonicPosition() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.monotonicPosition
+}
+
+// Like Position(), but without monotonic clamping nor end-of-video
+// detection side effects.
+func (c *videoWithAudioController) RawPosition() (time.Duration, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.audioPlayer == nil || c.needsFirstAudioFrameOffset {
+		return c.staticPosition, nil
+	}
+	return min(c.firstAudioFrameOffsetOnPlay+c.audioPlayer.Position(), c.duration), nil
+}
+
+// Returns the duration of the audio decoded but not yet handed to
+// ebitengine, plus the nominal audio player buffer size if the audio
+// player is active. ebitengine doesn't expose how full its internal
+// buffer actually is, so that part is an upper bound.
+func (c *videoWithAudioController) BufferedAudioDuration() time.Duration {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	buffered := audioBytesToDuration(len(c.leftoverAudio), c.sampleRate)
+	if c.audioPlayer != nil {
+		buffered += c.audioBufferSize
+	}
+	return buffered
+}
+
+func (c *videoWithAudioController) GetAudioBufferSize() time.Duration {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.audioBufferSize
+}
+
+func (c *videoWithAudioController) SetAudioBufferSize(bufferSize time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if bufferSize <= 0 {
+		bufferSize = defaultPlayerBufferSize
+	}
+	c.audioBufferSize = bufferSize
+	if c.audioPlayer != nil {
+		c.audioPlayer.SetBufferSize(bufferSize)
+	}
+}
+
+func (c *videoWithAudioController) GetAVSyncOffset() time.Duration {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.avSyncOffset
+}
+
+func (c *videoWithAudioController) SetAVSyncOffset(offset time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.avSyncOffset = offset
+}
+
+// Overrides the nominal frame duration, see Player.SetFrameRateOverride().
+// Audio-only controllers don't have frames, so they ignore it.
+func (c *videoWithAudioController) setFrameDuration(frameDuration time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.video != nil {
+		c.frameDuration = frameDuration
+	}
+}
+
+// Copies the audio configuration of the given controller, including the
+// audio processor, the audio sink and the progress of any mute fade, so
+// the audio sounds the same when switching from src to c. Used for
+// seamless looping, see Player.SetSeamlessLoop().
+func (c *videoWithAudioController) copyConfigFrom(src *videoWithAudioController) {
+	src.mutex.RLock()
+	volume, muted, muteFade, muteGain := src.volume, src.muted, src.muteFade, src.muteRamp.gain
+	pan, stereoSwap, bassGain, trebleGain := src.pan, src.stereoSwap, src.bassGain, src.trebleGain
+	processor, sink := src.audioProcessor, src.audioSink
+	monotonic, positionSource := src.monotonicPosition, src.positionSource
+	bufferSize, avSyncOffset := src.audioBufferSize, src.avSyncOffset
+	src.mutex.RUnlock()
+
+	c.SetVolume(volume)
+	c.SetMuted(muted)
+	c.SetMuteFadeDuration(muteFade)
+	c.SetPan(pan)
+	c.SetStereoSwap(stereoSwap)
+	c.SetBassGain(bassGain)
+	c.SetTrebleGain(trebleGain)
+	c.SetAudioProcessor(processor)
+	c.SetAudioSink(sink)
+	c.SetMonotonicPosition(monotonic)
+	c.SetPositionSource(positionSource)
+	c.SetAudioBufferSize(bufferSize)
+	c.SetAVSyncOffset(avSyncOffset)
+
+	c.mutex.Lock()
+	c.muteRamp.gain = muteGain
+	c.mutex.Unlock()
+}
+
+// Returns the RMS levels of the audio recently handed to ebitengine,
+// scaled by the effective volume. Zero while not playing.
+func (c *videoWithAudioController) AudioLevel() (float64, float64) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if c.state != Playing {
+		return 0, 0
+	}
+	// with a mute fade, muting is applied through the ramp instead
+	// of the effective volume, see noLockApplyMuteFade()
+	volume := c.getEffectiveVolume() * c.muteRamp.gain
+	return c.levelMeter.left * volume, c.levelMeter.right * volume
+}
+
+// Returns the magnitudes of the given amount of frequency bands for the
+// audio recently handed to ebitengine. Audio capture for the spectrum is
+// only enabled after the first call, so the first results will be zeros.
+func (c *videoWithAudioController) AudioSpectrum(bands int) []float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.spectrum.enabled() {
+		c.spectrum.configure(defaultSpectrumWindowSize, defaultSpectrumSmoothing)
+	}
+	return c.spectrum.bands(bands, c.sampleRate)
+}
+
+func (c *videoWithAudioController) SetSpectrumConfig(windowSize int, smoothing float64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.spectrum.configure(windowSize, smoothing)
+}
+
+func (c *videoWithAudioController) DroppedFrameCount() int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.droppedFrames
+}
+
+func (c *videoWithAudioController) DecodeStats() DecodeStats {
+	return c.decodeStats.stats()
+}
+
+func (c *videoWithAudioController) SetErrorHandler(handler func(error)) {
+	c.errReporter.setHandler(handler)
+}
+
 func (c *videoWithAudioController) Error() error {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 	return c.decodeErr
 }
 
-// --- videoController implementation ---
+// --- VideoController implementation ---
 
 func (c *videoWithAudioController) Play() error {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	c.lockMedia()
+	defer c.unlockMedia()
+	return c.noLockPlay()
+}
+
+// preconditions: c.mutex is locked, can't be called from c.Read()
+func (c *videoWithAudioController) noLockPlay() error {
 	if c.state != Playing {
 		if c.state == Stopped {
-			err := c.media.OpenDecode()
-			if err != nil {
-				return err
-			}
-			err = c.video.Open()
-			if err != nil {
-				return err
-			}
-			err = c.audio.Open()
+			err := c.noLockOpenStreams()
 			if err != nil {
 				return err
 			}
@@ -175,6 +577,14 @@ func (c *videoWithAudioController) Play() error {
 			c.lastReadFrame = nil
 			c.firstAudioFrameOffsetOnPlay = 0
 			c.decodeErr = nil
+			c.bassFilter.reset()
+			c.trebleFilter.reset()
+		} else if c.staticPosition >= c.duration {
+			// paused at the end due to EndPauseAtEnd, restart
+			err := c.noLockRewindToStart()
+			if err != nil {
+				return err
+			}
 		}
 
 		if c.audioPlayer == nil {
@@ -184,14 +594,67 @@ func (c *videoWithAudioController) Play() error {
 			}
 		}
 		c.state = Playing
+		c.stateSignal.notify()
 		c.audioPlayer.Play()
 	}
 	return nil
 }
 
+func (c *videoWithAudioController) Restart() error {
+	c.lockMedia()
+	defer c.unlockMedia()
+	if c.state != Stopped {
+		// the audio player is recreated, but the streams remain open
+		// and are rewound like when looping
+		err := c.noLockEnsureAudioHalt()
+		if err != nil {
+			return err
+		}
+		err = c.noLockRewindToStart()
+		if err != nil {
+			return err
+		}
+		c.videoPendingLoop = false
+		c.positionFloor = 0
+		c.state = Paused
+		c.stateSignal.notify()
+	}
+	return c.noLockPlay()
+}
+
+func (c *videoWithAudioController) Prime() (*reisen.VideoFrame, error) {
+	c.lockMedia()
+	defer c.unlockMedia()
+	if c.state != Stopped {
+		return nil, nil
+	}
+
+	err := c.noLockOpenStreams()
+	if err != nil {
+		return nil, err
+	}
+
+	// same resets as in Play(), but we end up paused at 0 instead,
+	// and the audio player is only created once we actually play
+	c.leftoverAudio = c.leftoverAudio[:0]
+	c.leftoverVideo = c.leftoverVideo[:0]
+	c.lastReadFrame = nil
+	c.firstAudioFrameOffsetOnPlay = 0
+	c.needsFirstAudioFrameOffset = true
+	c.staticPosition = 0
+	c.decodeErr = nil
+	c.bassFilter.reset()
+	c.trebleFilter.reset()
+	c.state = Paused
+	c.stateSignal.notify()
+
+	c.lastReadFrame, err = c.internalReadFirstVideoFrame(true)
+	return c.lastReadFrame, err
+}
+
 func (c *videoWithAudioController) Pause() error {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	c.lockMedia()
+	defer c.unlockMedia()
 	if c.state != Playing {
 		return nil
 	}
@@ -202,26 +665,48 @@ func (c *videoWithAudioController) Pause() error {
 	}
 	if !endedAsSideEffect {
 		c.state = Paused
+		c.stateSignal.notify()
 
 		err := c.noLockEnsureAudioHalt()
 		if err != nil {
 			return err
 		}
+		c.noLockRestorePausedAudio(position)
 		c.firstAudioFrameOffsetOnPlay = position
 		c.staticPosition = position
 	}
 	return nil
 }
 
+// Halting the audio player discards the audio it had buffered but not
+// played yet, so decoding would resume ahead of the paused position and
+// the position would jump forward on the next play. To avoid this, the
+// pending audio is restored from the history, starting exactly at the
+// given position, and the audio clock resumes from there (see Read()).
+// The video frames after the position are still in c.leftoverVideo.
+//
+// preconditions: c.mutex is locked, the audio player has been halted
+func (c *videoWithAudioController) noLockRestorePausedAudio(position time.Duration) {
+	sampleRate := c.sampleRate
+	audioStart := c.audioHistoryEnd - audioBytesToDuration(len(c.audioHistory), sampleRate)
+	if len(c.audioHistory) == 0 || position < audioStart || position >= c.audioHistoryEnd {
+		return // not covered, e.g. right after a seek. decoding simply continues
+	}
+	skip := durationToAudioBytes(position-audioStart, sampleRate)
+	c.leftoverAudio = append(c.leftoverAudio[:0], c.audioHistory[skip:]...)
+	c.leftoverAudioOffset = audioStart + audioBytesToDuration(skip, sampleRate)
+}
+
 func (c *videoWithAudioController) Stop() error {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	c.lockMedia()
+	defer c.unlockMedia()
 	return c.noLockStop(stopModeManual)
 }
 
 func (c *videoWithAudioController) Close() error {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	c.lockMedia()
+	defer c.unlockMedia()
+	masterVolume.unregister(c)
 	err := c.noLockStop(stopModeManual)
 	if err != nil {
 		return err
@@ -230,9 +715,13 @@ func (c *videoWithAudioController) Close() error {
 	return nil
 }
 
+func (c *videoWithAudioController) stateChanged() <-chan struct{} {
+	return c.stateSignal.changed()
+}
+
 func (c *videoWithAudioController) State() (PlaybackState, error) {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	c.lockMediaIfIdle()
+	defer c.unlockMedia()
 	// we call c.noLockPosition for its side-effects: if the
 	// video has reached the end, that will be detected and
 	// reflected on c.state
@@ -242,15 +731,141 @@ func (c *videoWithAudioController) State() (PlaybackState, error) {
 	return c.state, nil
 }
 
-func (c *videoWithAudioController) Seek(time.Duration) (*reisen.VideoFrame, error) {
-	panic("unimplemented")
+func (c *videoWithAudioController) PeekState() PlaybackState {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.state
+}
+
+func (c *videoWithAudioController) Seek(position time.Duration) (*reisen.VideoFrame, error) {
+	c.lockMedia()
+	defer c.unlockMedia()
+
+	if position >= c.duration {
+		// see videoOnlyController.Seek()
+		err := c.noLockStop(stopModeManual)
+		return nil, err
+	}
+	position = max(position, 0)
+
+	// the streams are closed while stopped, so we reopen them
+	// and leave the video paused at the new position
+	wasPlaying := c.state == Playing
+	if c.state == Stopped {
+		err := c.noLockOpenStreams()
+		if err != nil {
+			return nil, err
+		}
+		c.decodeErr = nil
+		c.state = Paused
+		c.stateSignal.notify()
+	}
+
+	// try to replay recently decoded data first, and otherwise
+	// rewind and decode up to the target position
+	served, err := c.noLockSeekBuffered(position)
+	if err != nil {
+		return nil, err
+	}
+	if !served {
+		err = c.noLockEnsureAudioHalt()
+		if err != nil {
+			return nil, err
+		}
+		err = c.noLockRewindStreams(position)
+		if err != nil {
+			return nil, err
+		}
+		c.leftoverVideo = c.leftoverVideo[:0]
+		c.lastDecodedOffset = position
+		c.bassFilter.reset()
+		c.trebleFilter.reset()
+		c.lastReadFrame, err = c.internalReadVideoFrameAt(position)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.videoPendingLoop = false
+	c.positionFloor = 0
+	c.staticPosition = position
+	c.firstAudioFrameOffsetOnPlay = position
+	if wasPlaying {
+		err = c.noLockHackyAudioReset()
+	}
+	return c.lastReadFrame, err
+}
+
+// Serves a seek to the given position by replaying the kept audio and
+// video history, if the position falls within it. The audio player is
+// still recreated, as the data it has already buffered must be dropped,
+// but nothing needs to be decoded again. Returns false if the position
+// falls outside the history window.
+//
+// preconditions: c.mutex is locked, can't be called from c.Read() if c.audioPlayer != nil
+func (c *videoWithAudioController) noLockSeekBuffered(position time.Duration) (bool, error) {
+	if c.seekBufferDuration == 0 || len(c.audioHistory) == 0 {
+		return false, nil
+	}
+	sampleRate := c.sampleRate
+	audioStart := c.audioHistoryEnd - audioBytesToDuration(len(c.audioHistory), sampleRate)
+	if position < audioStart || position >= c.audioHistoryEnd {
+		return false, nil
+	}
+
+	// find the last frame starting at or before the target position
+	frameIndex := -1
+	if c.video != nil {
+		for i, frame := range c.videoHistory {
+			presOffset, err := frame.PresentationOffset()
+			if err != nil {
+				return false, err
+			}
+			if presOffset > position {
+				break
+			}
+			frameIndex = i
+		}
+		if frameIndex < 0 {
+			return false, nil
+		}
+	}
+
+	// replace the pending data with the history from the target position.
+	// the history also includes the data that was pending, and decoding
+	// simply continues after it
+	err := c.noLockEnsureAudioHalt()
+	if err != nil {
+		return false, err
+	}
+	skip := durationToAudioBytes(position-audioStart, sampleRate)
+	c.leftoverAudio = append(c.leftoverAudio[:0], c.audioHistory[skip:]...)
+	c.leftoverAudioOffset = audioStart + audioBytesToDuration(skip, sampleRate)
+	if c.video != nil {
+		c.lastReadFrame = c.videoHistory[frameIndex]
+		c.leftoverVideo = append(c.leftoverVideo[:0], c.videoHistory[frameIndex+1:]...)
+	}
+	return true, nil
+}
+
+func (*videoWithAudioController) IsSeekable() bool {
+	return true
 }
 
 func (c *videoWithAudioController) Position() (time.Duration, error) {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-	position, _, err := c.noLockPosition()
-	return position, err
+	c.lockMediaIfIdle()
+	defer c.unlockMedia()
+	position, ended, err := c.noLockPosition()
+	if err != nil || ended || c.positionSource != PositionVideoFrame {
+		return position, err
+	}
+
+	// report the frame on screen instead. the audio clock is still used
+	// internally, as frames are selected based on it
+	if c.state == Stopped || c.lastReadFrame == nil || position >= c.duration {
+		return position, nil
+	}
+	return c.lastReadFrame.PresentationOffset()
 }
 
 func (c *videoWithAudioController) Duration() time.Duration {
@@ -271,9 +886,33 @@ func (c *videoWithAudioController) GetLooping() bool {
 	return c.looping
 }
 
-func (c *videoWithAudioController) CurrentVideoFrame() (*reisen.VideoFrame, bool, error) {
+func (c *videoWithAudioController) LoopCount() int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.loopCount
+}
+
+func (c *videoWithAudioController) GetLoopStart() time.Duration {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.loopStart
+}
+
+func (c *videoWithAudioController) SetLoopStart(position time.Duration) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
+	c.loopStart = clampLoopStart(position, c.duration, c.frameDuration)
+}
+
+func (c *videoWithAudioController) SetEndBehavior(behavior EndBehavior) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.endBehavior = behavior
+}
+
+func (c *videoWithAudioController) CurrentVideoFrame() (*reisen.VideoFrame, bool, error) {
+	c.lockMediaIfIdle()
+	defer c.unlockMedia()
 
 	if c.state == Stopped {
 		// NOTICE: here we don't touch c.leftoverVideo because stopping can
@@ -289,12 +928,7 @@ func (c *videoWithAudioController) CurrentVideoFrame() (*reisen.VideoFrame, bool
 	}
 
 	if endedAsSideEffect {
-		// natural end of video, take any remaining leftover video frames we can
-		if len(c.leftoverVideo) > 0 {
-			c.lastReadFrame = c.leftoverVideo[len(c.leftoverVideo)-1]
-			c.leftoverVideo = c.leftoverVideo[:0]
-		}
-		// further frame exhaustion would depend on noLockPosition behavior
+		// remaining frames were already exhausted by noLockEndOfVideo()
 		return c.lastReadFrame, true, nil
 	}
 
@@ -309,12 +943,17 @@ func (c *videoWithAudioController) CurrentVideoFrame() (*reisen.VideoFrame, bool
 	}
 
 	// consume leftover video frames until we reach the target position
+	// (the sync offset only affects frame selection, not the clock)
+	position -= c.avSyncOffset
 	var leftoverIndex int
 	for len(c.leftoverVideo) > leftoverIndex && (presOffset+c.frameDuration < position || c.videoPendingLoop) {
 		if c.videoPendingLoop && presOffset < prevPresOffset {
 			c.videoPendingLoop = false
 		}
 
+		if leftoverIndex > 0 { // previous frame is being replaced before being returned
+			c.droppedFrames += 1
+		}
 		c.lastReadFrame = c.leftoverVideo[leftoverIndex]
 		leftoverIndex += 1
 
@@ -344,10 +983,39 @@ func (c *videoWithAudioController) CurrentVideoFrame() (*reisen.VideoFrame, bool
 // --- internal ---
 
 func (c *videoWithAudioController) getEffectiveVolume() float64 {
-	if c.muted {
+	if c.muted && c.muteFade == 0 { // otherwise, see noLockApplyMuteFade()
 		return 0.0
 	}
-	return c.volume
+	return c.volume * masterVolume.get()
+}
+
+// Applies the current effective volume to the audio player, if any.
+// Used when the master volume changes, see SetMasterVolume().
+func (c *videoWithAudioController) refreshVolume() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.audioPlayer != nil {
+		c.audioPlayer.SetVolume(c.getEffectiveVolume())
+	}
+}
+
+// Fades the served audio data towards silence when muted, or back to
+// full gain when unmuted. Without a mute fade, the gain simply snaps to
+// the target, as muting is handled by getEffectiveVolume() instead.
+//
+// preconditions: c.mutex is locked
+func (c *videoWithAudioController) noLockApplyMuteFade(data []byte) {
+	target := 1.0
+	if c.muted {
+		target = 0.0
+	}
+	if c.muteFade == 0 {
+		c.muteRamp.gain = target
+		return
+	}
+
+	step := 1.0 / (c.muteFade.Seconds() * float64(c.sampleRate))
+	c.muteRamp.apply(data, target, step)
 }
 
 // the returned bool will be true if the video ending is handled as
@@ -362,21 +1030,163 @@ func (c *videoWithAudioController) noLockPosition() (time.Duration, bool, error)
 
 	position := c.firstAudioFrameOffsetOnPlay + c.audioPlayer.Position()
 	if position < c.duration {
+		// audio player positions can jitter slightly, so we
+		// optionally prevent them from going backwards
+		if c.monotonicPosition {
+			position = max(position, c.positionFloor)
+			c.positionFloor = position
+		}
 		return position, false, nil
 	}
 
-	// here exhausting video frames to fetch the latest one could be reasonable,
-	// but it also comes with some risks, and in practice should be limited to
-	// a maximum decoding of only a few frames. we are avoiding this whole process
-	// at the moment for simplicity
-	// c.exhaustVideoFrames()
-
-	err := c.noLockStop(stopModeEndOfVideo)
+	if !c.mediaLocked {
+		// Read() is decoding, see lockMediaIfIdle(). the end of the
+		// video will be handled on a later call
+		return c.duration, false, nil
+	}
+	err := c.noLockEndOfVideo()
 	return c.duration, true, err
 }
 
+// Locks c.decodeMutex and c.mutex, in that order. Used by the methods
+// that may access the media, so they wait for Read() to complete when
+// it decodes without holding c.mutex (see PlayerOptions.BackgroundDecoding).
+// Must be paired with unlockMedia().
+func (c *videoWithAudioController) lockMedia() {
+	c.decodeMutex.Lock()
+	c.mutex.Lock()
+	c.mediaLocked = true
+}
+
+// Like lockMedia(), but with background decoding c.decodeMutex is only
+// locked if Read() is not decoding, so frequent calls from the update
+// loop don't wait for it. The media can't be accessed if c.mediaLocked
+// remains false. Must be paired with unlockMedia().
+func (c *videoWithAudioController) lockMediaIfIdle() {
+	if !c.backgroundDecoding {
+		c.lockMedia()
+		return
+	}
+	locked := c.decodeMutex.TryLock()
+	c.mutex.Lock()
+	c.mediaLocked = locked
+}
+
+func (c *videoWithAudioController) unlockMedia() {
+	locked := c.mediaLocked
+	c.mediaLocked = false
+	c.mutex.Unlock()
+	if locked {
+		c.decodeMutex.Unlock()
+	}
+}
+
+// Releases c.mutex while Read() reads packets or decodes frames, if
+// background decoding is enabled. c.decodeMutex remains locked, so only
+// the state that doesn't involve the media can change in the meantime.
+// Must be paired with endUnlockedDecoding().
+// preconditions: c.mutex is locked through lockMedia(), called from c.Read()
+func (c *videoWithAudioController) beginUnlockedDecoding() {
+	if c.backgroundDecoding {
+		c.mediaLocked = false
+		c.mutex.Unlock()
+	}
+}
+
+func (c *videoWithAudioController) endUnlockedDecoding() {
+	if c.backgroundDecoding {
+		c.mutex.Lock()
+		c.mediaLocked = true
+	}
+}
+
+// Handles the natural end of the video according to c.endBehavior.
+// preconditions: c.mutex is locked, can't be called from c.Read() if c.audioPlayer != nil
+func (c *videoWithAudioController) noLockEndOfVideo() error {
+	exhaustErr := c.noLockExhaustVideoFrames()
+	return errors.Join(exhaustErr, c.noLockHandleEndBehavior())
+}
+
+// Decodes the video frames remaining at the end of the video, up to
+// maxEndOfVideoFrames, and presents the latest pending one, so the video
+// always freezes on its last frame, regardless of whether the end was
+// detected through the audio clock or by running out of audio data.
+// preconditions: c.mutex is locked
+func (c *videoWithAudioController) noLockExhaustVideoFrames() error {
+	var err error
+	for decodedFrames := 0; c.video != nil && decodedFrames < maxEndOfVideoFrames; {
+		packet, packetFound, readErr := c.media.ReadPacket()
+		if readErr != nil {
+			err = c.noLockFilterDecodeError(readErr)
+			break
+		}
+		if !packetFound {
+			break
+		}
+		if packet.Type() != reisen.StreamVideo || packet.StreamIndex() != c.video.Index() {
+			continue
+		}
+
+		frame, _, readErr := c.decodeStats.readVideoFrame(c.video)
+		if readErr != nil {
+			err = c.noLockFilterDecodeError(readErr)
+			break
+		}
+		if frame != nil {
+			c.lastDecodedOffset, _ = frame.PresentationOffset()
+			c.leftoverVideo = append(c.leftoverVideo, frame)
+			c.noLockCapLeftoverVideo()
+			decodedFrames += 1
+		}
+	}
+
+	// present the latest frame, even if decoding failed
+	if len(c.leftoverVideo) > 0 {
+		c.droppedFrames += len(c.leftoverVideo) - 1
+		c.lastReadFrame = c.leftoverVideo[len(c.leftoverVideo)-1]
+		c.leftoverVideo = c.leftoverVideo[:0]
+	}
+	return err
+}
+
+// preconditions: c.mutex is locked, can't be called from c.Read() if c.audioPlayer != nil
+func (c *videoWithAudioController) noLockHandleEndBehavior() error {
+	if c.endBehavior == EndPauseAtEnd {
+		err := c.noLockEnsureAudioHalt()
+		c.state = Paused
+		c.stateSignal.notify()
+		c.firstAudioFrameOffsetOnPlay = c.duration
+		c.staticPosition = c.duration
+		c.videoPendingLoop = false
+		c.positionFloor = 0
+		return err
+	}
+	return c.noLockStop(stopModeEndOfVideo)
+}
+
+// Rewinds the open streams to the start, discarding any pending data.
+// preconditions: c.mutex is locked
+func (c *videoWithAudioController) noLockRewindToStart() error {
+	err := c.noLockRewindStreams(0)
+	if err != nil {
+		return err
+	}
+	c.leftoverAudio = c.leftoverAudio[:0]
+	c.leftoverVideo = c.leftoverVideo[:0]
+	c.lastReadFrame = nil
+	c.firstAudioFrameOffsetOnPlay = 0
+	c.staticPosition = 0
+	c.lastDecodedOffset = 0
+	c.bassFilter.reset()
+	c.trebleFilter.reset()
+	return nil
+}
+
 // preconditions: c.mutex is locked, can't be called from c.Read() if c.audioPlayer != nil
 func (c *videoWithAudioController) noLockStop(videoStopMode stopMode) error {
+	// the position can legitimately go back after stopping
+	c.positionFloor = 0
+
 	// manual stops need to be handled even if already stopped due to end-of-video
 	if videoStopMode == stopModeManual {
 		err := c.noLockEnsureAudioHalt()
@@ -397,6 +1207,7 @@ func (c *videoWithAudioController) noLockStop(videoStopMode stopMode) error {
 
 	// stopping logic
 	c.state = Stopped
+	c.stateSignal.notify()
 	if videoStopMode == stopModeEndOfVideo {
 		err := c.noLockEnsureAudioHalt()
 		if err != nil {
@@ -408,20 +1219,18 @@ func (c *videoWithAudioController) noLockStop(videoStopMode stopMode) error {
 	}
 
 	// rewind streams
-	var err error
-	err = c.video.Rewind(0)
-	if err != nil {
-		return err
-	}
-	err = c.audio.Rewind(0)
+	c.lastDecodedOffset = 0
+	err := c.noLockRewindStreams(0)
 	if err != nil {
 		return err
 	}
 
 	// close streams
-	err = c.video.Close()
-	if err != nil {
-		return err
+	if c.video != nil {
+		err = c.video.Close()
+		if err != nil {
+			return err
+		}
 	}
 	err = c.audio.Close()
 	if err != nil {
@@ -444,6 +1253,13 @@ func (c *videoWithAudioController) noLockEnsureAudioHalt() error {
 	}
 	c.leftoverAudio = c.leftoverAudio[:0]
 	c.needsFirstAudioFrameOffset = true
+	c.levelMeter.reset()
+	c.spectrum.reset()
+	if c.muted { // no need to fade in or out when audio restarts
+		c.muteRamp.gain = 0.0
+	} else {
+		c.muteRamp.gain = 1.0
+	}
 	return nil
 }
 
@@ -453,6 +1269,7 @@ func (c *videoWithAudioController) noLockEnsureAudioHalt() error {
 func (c *videoWithAudioController) readHandleError(err error) error {
 	if err != nil && c.decodeErr == nil {
 		c.decodeErr = err
+		c.errReporter.queue(err) // flushed at the end of Read()
 	}
 	// we ignore errors from noLockStop here to avoid cascading failures
 	_ = c.noLockStop(stopModeEndOfVideo)
@@ -472,12 +1289,19 @@ func (c *videoWithAudioController) Read(buffer []byte) (int, error) {
 		}
 	}
 
-	// mutex
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	// mutex. errors are reported after unlocking, so deferred first
+	defer c.errReporter.flush()
+	c.lockMedia()
+	defer c.unlockMedia()
 
-	// if we had leftover bytes from the previous read, use that
+	// if we had leftover bytes from the previous read, use that. leftover
+	// bytes on a new audio player come from a buffered seek, in which case
+	// the audio clock starts from them
 	var servedBytes int
+	if c.needsFirstAudioFrameOffset && len(c.leftoverAudio) > 0 {
+		c.firstAudioFrameOffsetOnPlay = c.leftoverAudioOffset
+		c.needsFirstAudioFrameOffset = false
+	}
 	if len(c.leftoverAudio) > 0 {
 		copiedBytes := c.noLockCopyLeftoverAudio(buffer)
 		buffer = buffer[copiedBytes:]
@@ -489,14 +1313,25 @@ func (c *videoWithAudioController) Read(buffer []byte) (int, error) {
 	}
 
 	// decode audio and move it into the buffer
+	packetBudget := c.maxPacketsPerRead
 	for len(buffer) > 0 {
 		// try to decode one audio frame (data is placed on c.leftoverAudio)
-		if err := c.internalReadAudioFrame(); err != nil {
+		packetsRead, err := c.internalReadAudioFrame(packetBudget)
+		if err != nil {
 			// real decode error: remember it, gracefully stop, and tell Ebiten
 			// that the stream has finished (EOF), without crashing RunGame.
 			return servedBytes, c.readHandleError(err)
 		}
 
+		// packet budget exhausted before finding more audio: serve what we
+		// have and continue on the next read, see PlayerOptions.MaxPacketsPerRead
+		if packetBudget >= 0 {
+			packetBudget -= packetsRead
+			if packetBudget == 0 && len(c.leftoverAudio) == 0 {
+				return servedBytes, nil
+			}
+		}
+
 		// check EOF case
 		if len(c.leftoverAudio) == 0 {
 			// setting audioPlayer == nil and returning io.EOF will stop the player
@@ -520,7 +1355,7 @@ func (c *videoWithAudioController) Read(buffer []byte) (int, error) {
 			}
 
 			// end of video
-			err := c.noLockStop(stopModeEndOfVideo)
+			err := c.noLockEndOfVideo()
 			if err != nil {
 				return servedBytes, c.readHandleError(err)
 			}
@@ -539,6 +1374,11 @@ func (c *videoWithAudioController) Read(buffer []byte) (int, error) {
 
 func (c *videoWithAudioController) noLockCopyLeftoverAudio(buffer []byte) int {
 	copiedBytes := copy(buffer, c.leftoverAudio)
+	c.levelMeter.add(buffer[:copiedBytes])
+	c.spectrum.add(buffer[:copiedBytes])
+	c.noLockWriteAudioSink(buffer[:copiedBytes])
+	c.noLockApplyMuteFade(buffer[:copiedBytes])
+	c.leftoverAudioOffset += audioBytesToDuration(copiedBytes, c.sampleRate)
 	if copiedBytes >= len(c.leftoverAudio) {
 		c.leftoverAudio = c.leftoverAudio[:0]
 	} else {
@@ -551,29 +1391,91 @@ func (c *videoWithAudioController) noLockCopyLeftoverAudio(buffer []byte) int {
 	return copiedBytes
 }
 
+// Tees the given served audio data to the audio sink, if any. The data
+// is written before muting and volume are applied.
+//
+// preconditions: c.mutex is locked
+func (c *videoWithAudioController) noLockWriteAudioSink(data []byte) {
+	if c.audioSink == nil || len(data) == 0 {
+		return
+	}
+	_, err := c.audioSink.Write(data)
+	if err != nil {
+		c.audioSink = nil
+		c.errReporter.queue(fmt.Errorf("audio sink write failed: %w", err))
+	}
+}
+
 // preconditions: c.mutex is locked
 func (c *videoWithAudioController) noLockRewindForLooping() error {
-	var err error
-	err = c.audio.Rewind(0)
+	// notice: the audio clock offset will be taken from the first
+	// audio frame after the rewind, so we don't need to adjust it
+	err := c.noLockRewindStreams(c.loopStart)
 	if err != nil {
 		return err
 	}
-	err = c.video.Rewind(0)
+	c.videoPendingLoop = true
+	c.loopCount += 1
+	c.positionFloor = 0
+	c.lastDecodedOffset = c.loopStart
+	return nil
+}
+
+// Returns nil if the decode error must be treated as the end of the
+// stream instead. See PlayerOptions.TolerateTruncatedEnd.
+//
+// preconditions: c.mutex is locked
+func (c *videoWithAudioController) noLockFilterDecodeError(err error) error {
+	if !c.tolerateTruncatedEnd || !isTruncatedEnd(c.lastDecodedOffset, c.duration) {
+		return err
+	}
+	c.logger.Printf("WARNING: decode error at %s, treating as end of video: %s", c.lastDecodedOffset, err)
+	return nil
+}
+
+// preconditions: c.mutex is locked
+func (c *videoWithAudioController) noLockOpenStreams() error {
+	err := c.media.OpenDecode()
 	if err != nil {
 		return err
 	}
-	c.videoPendingLoop = true
+	if c.video != nil {
+		err = openVideoDecode(c.video, c.decodeWidth, c.decodeHeight)
+		if err != nil {
+			return err
+		}
+	}
+	return c.audio.Open()
+}
+
+// preconditions: c.mutex is locked
+func (c *videoWithAudioController) noLockRewindStreams(position time.Duration) error {
+	// the history must be contiguous with the decoded data
+	c.audioHistory = c.audioHistory[:0]
+	clear(c.videoHistory)
+	c.videoHistory = c.videoHistory[:0]
+	if c.resampler != nil {
+		c.resampler.reset()
+	}
+
+	err := c.audio.Rewind(position)
+	if err != nil {
+		return err
+	}
+	if c.video != nil {
+		return c.video.Rewind(position)
+	}
 	return nil
 }
 
 // preconditions: c.mutex is locked
 func (c *videoWithAudioController) noLockCreateAudioPlayer() error {
-	var err error
-	c.audioPlayer, err = audio.CurrentContext().NewPlayer(&struct{ io.Reader }{c})
+	player, err := newAudioOutput(&struct{ io.Reader }{c})
 	if err != nil {
 		return err
 	}
-	c.audioPlayer.SetBufferSize(playerBufferSize)
+	c.audioPlayer = player
+	c.audioPlayer.SetBufferSize(c.audioBufferSize)
 	c.audioPlayer.SetVolume(c.getEffectiveVolume())
 	c.needsFirstAudioFrameOffset = true
 	return nil
@@ -596,64 +1498,271 @@ func (c *videoWithAudioController) noLockHackyAudioReset() error {
 	return nil
 }
 
-func (c *videoWithAudioController) internalReadAudioFrame() error {
-	// read packets until we come across the next audio frame packet
+// reads video frames until the one presented at the given position,
+// skipping audio packets on the way like internalReadFirstVideoFrame().
+// If the stream ends before, the last decoded frame is returned.
+//
+// preconditions: c.mutex is locked
+func (c *videoWithAudioController) internalReadVideoFrameAt(position time.Duration) (*reisen.VideoFrame, error) {
+	var lastFrame *reisen.VideoFrame
+	for {
+		frame, err := c.internalReadFirstVideoFrame(false)
+		if err != nil || frame == nil {
+			return lastFrame, err
+		}
+		lastFrame = frame
+		presOffset, err := frame.PresentationOffset()
+		if err != nil {
+			return nil, err
+		}
+		if presOffset+c.frameDuration > position {
+			return lastFrame, nil
+		}
+	}
+}
+
+// Appends freshly decoded audio data to the history used for buffered
+// seeks and pauses, discarding the oldest data once it exceeds the seek
+// buffer duration, or the audio player buffer size plus pauseHistoryMargin
+// if that's larger. The history is restarted if the data is not contiguous.
+//
+// preconditions: c.mutex is locked
+func (c *videoWithAudioController) noLockRecordAudioHistory(data []byte, presOffset time.Duration) {
+	if len(c.audioHistory) > 0 && (presOffset-c.audioHistoryEnd).Abs() > seekHistoryGapTolerance {
+		c.audioHistory = c.audioHistory[:0]
+	}
+	sampleRate := c.sampleRate
+	c.audioHistory = append(c.audioHistory, data...)
+	c.audioHistoryEnd = presOffset + audioBytesToDuration(len(data), sampleRate)
+
+	// trim in large steps to avoid moving data on every frame
+	historyDuration := max(c.seekBufferDuration, c.audioBufferSize+pauseHistoryMargin)
+	maxBytes := durationToAudioBytes(historyDuration, sampleRate)
+	if len(c.audioHistory) > 2*maxBytes {
+		kept := copy(c.audioHistory, c.audioHistory[len(c.audioHistory)-maxBytes:])
+		c.audioHistory = c.audioHistory[:kept]
+	}
+}
+
+// Appends a freshly decoded video frame to the history used for buffered
+// seeks, discarding frames older than the seek buffer duration or exceeding
+// the leftover video frames limit.
+//
+// preconditions: c.mutex is locked
+func (c *videoWithAudioController) noLockRecordVideoHistory(frame *reisen.VideoFrame) {
+	if c.seekBufferDuration == 0 {
+		return
+	}
+	presOffset, err := frame.PresentationOffset()
+	if err != nil {
+		return
+	}
+	c.videoHistory = append(c.videoHistory, frame)
+
+	var excess int
+	if c.maxLeftoverVideo >= 0 {
+		excess = max(len(c.videoHistory)-c.maxLeftoverVideo, 0)
+	}
+	for excess < len(c.videoHistory)-1 {
+		oldest, err := c.videoHistory[excess].PresentationOffset()
+		if err != nil || oldest >= presOffset-c.seekBufferDuration {
+			break
+		}
+		excess += 1
+	}
+	if excess > 0 {
+		kept := copy(c.videoHistory, c.videoHistory[excess:])
+		clear(c.videoHistory[kept:])
+		c.videoHistory = c.videoHistory[:kept]
+	}
+}
+
+// discards the oldest leftover video frames if there are more than
+// allowed. CurrentVideoFrame() compares presentation offsets against
+// the last returned frame, so skipping frames here doesn't break its
+// catch-up logic nor the loop detection.
+//
+// preconditions: c.mutex is locked
+func (c *videoWithAudioController) noLockCapLeftoverVideo() {
+	if c.maxLeftoverVideo < 0 || len(c.leftoverVideo) <= c.maxLeftoverVideo {
+		return
+	}
+
+	excess := len(c.leftoverVideo) - c.maxLeftoverVideo
+	kept := copy(c.leftoverVideo, c.leftoverVideo[excess:])
+	clear(c.leftoverVideo[kept:])
+	c.leftoverVideo = c.leftoverVideo[:kept]
+	c.droppedFrames += excess
+}
+
+// applies the configured audio effects to freshly decoded L16 stereo
+// data, in place, before it's queued for ebitengine.
+//
+// preconditions: c.mutex is locked
+func (c *videoWithAudioController) noLockProcessAudio(data []byte) {
+	// swapping goes first, so everything else sees the corrected channels
+	if c.stereoSwap {
+		swapStereoL16(data)
+	}
+
+	// skip sample conversions when there's nothing to do
+	if c.audioProcessor == nil && c.pan == 0 && !c.bassFilter.active && !c.trebleFilter.active {
+		return
+	}
+
+	c.sampleBuffer = decodeSamplesL16(c.sampleBuffer, data)
+	if c.audioProcessor != nil {
+		c.audioProcessor(c.sampleBuffer, audioChannelCount)
+	}
+	applyFilters(c.sampleBuffer, &c.bassFilter, &c.trebleFilter)
+	leftGain, rightGain := panGains(c.pan)
+	applyStereoGains(c.sampleBuffer, leftGain, rightGain)
+	encodeSamplesL16(data, c.sampleBuffer)
+}
+
+// reads packets until the first video frame is decoded. if keepAudio is
+// true, audio packets found along the way are decoded into c.leftoverAudio
+// like internalReadAudioFrame() does, so no audio is lost when playing from
+// the start. otherwise, they are skipped instead of decoded: the audio clock
+// will simply start from the first audio frame decoded after this.
+//
+// preconditions: c.mutex is locked
+func (c *videoWithAudioController) internalReadFirstVideoFrame(keepAudio bool) (*reisen.VideoFrame, error) {
+	if c.video == nil {
+		return nil, nil
+	}
 	for {
 		packet, packetFound, err := c.media.ReadPacket()
 		if err != nil {
-			return err
+			return nil, c.noLockFilterDecodeError(err)
+		}
+
+		if !packetFound {
+			if packet != nil {
+				panic("broken code")
+			}
+			return nil, nil
+		}
+
+		if packet.Type() == reisen.StreamVideo && packet.StreamIndex() == c.video.Index() {
+			frame, frameFound, err := c.decodeStats.readVideoFrame(c.video)
+			if err != nil {
+				return nil, c.noLockFilterDecodeError(err)
+			}
+			_ = frameFound // frameFound can be true while frame is nil: that's a frame skip
+			if frame != nil {
+				c.lastDecodedOffset, _ = frame.PresentationOffset()
+				c.noLockRecordVideoHistory(frame)
+				return frame, nil
+			}
+		} else if keepAudio && packet.Type() == reisen.StreamAudio && packet.StreamIndex() == c.audio.Index() {
+			frame, frameFound, err := c.audio.ReadAudioFrame()
+			if err != nil {
+				return nil, c.noLockFilterDecodeError(err)
+			}
+			_ = frameFound // frameFound can be true while frame is nil: that's a frame skip
+			if frame != nil {
+				presOffset, err := frame.PresentationOffset()
+				if err != nil {
+					return nil, err
+				}
+				c.noLockQueueDecodedAudio(frame.Data(), presOffset)
+			}
+		}
+	}
+}
+
+// Resamples and processes freshly decoded audio data with the given
+// presentation offset, and appends it to c.leftoverAudio and the history.
+// If this is the first audio since play, the audio clock starts from it.
+//
+// preconditions: c.mutex is locked
+func (c *videoWithAudioController) noLockQueueDecodedAudio(data []byte, presOffset time.Duration) {
+	if c.resampler != nil {
+		data = c.resampler.resample(data)
+	}
+	c.noLockProcessAudio(data)
+	if len(c.leftoverAudio) == 0 {
+		c.leftoverAudioOffset = presOffset
+	}
+	c.leftoverAudio = append(c.leftoverAudio, data...)
+	c.noLockRecordAudioHistory(data, presOffset)
+
+	// if first audio frame since play, store its offset
+	if c.needsFirstAudioFrameOffset {
+		c.firstAudioFrameOffsetOnPlay = presOffset
+		c.needsFirstAudioFrameOffset = false
+	}
+}
+
+// Reads packets until the next audio frame is decoded into c.leftoverAudio,
+// the end of the stream is reached, or maxPackets packets have been read
+// (negative for no limit). Returns the amount of packets read.
+// preconditions: c.mutex is locked through lockMedia(), called from c.Read().
+// c.mutex is released while reading, see beginUnlockedDecoding()
+func (c *videoWithAudioController) internalReadAudioFrame(maxPackets int) (int, error) {
+	// read packets until we come across the next audio frame packet
+	var packetsRead int
+	for maxPackets < 0 || packetsRead < maxPackets {
+		c.beginUnlockedDecoding()
+		packet, packetFound, err := c.media.ReadPacket()
+		c.endUnlockedDecoding()
+		if err != nil {
+			return packetsRead, c.noLockFilterDecodeError(err)
 		}
 
 		if !packetFound {
 			if packet != nil {
 				panic("broken code")
 			}
-			return nil
+			return packetsRead, nil
 		}
+		packetsRead += 1
 
 		switch packet.Type() {
 		case reisen.StreamVideo:
-			if packet.StreamIndex() != c.video.Index() {
+			if c.video == nil || packet.StreamIndex() != c.video.Index() {
 				continue
 			}
-			frame, frameFound, err := c.video.ReadVideoFrame()
+			c.beginUnlockedDecoding()
+			frame, frameFound, err := c.decodeStats.readVideoFrame(c.video)
+			c.endUnlockedDecoding()
 			if err != nil {
-				return err
+				return packetsRead, c.noLockFilterDecodeError(err)
 			}
 			_ = frameFound // frameFound can be true while frame is nil: that's a frame skip
 			if frame != nil {
+				c.lastDecodedOffset, _ = frame.PresentationOffset()
 				c.leftoverVideo = append(c.leftoverVideo, frame)
+				c.noLockCapLeftoverVideo()
+				c.noLockRecordVideoHistory(frame)
 			}
 		case reisen.StreamAudio:
 			if packet.StreamIndex() != c.audio.Index() {
 				continue
 			}
+			c.beginUnlockedDecoding()
 			frame, frameFound, err := c.audio.ReadAudioFrame()
+			c.endUnlockedDecoding()
 			if err != nil {
-				return err
+				return packetsRead, c.noLockFilterDecodeError(err)
 			}
 			_ = frameFound // frameFound can be true while frame is nil: that's a frame skip
 			if frame != nil {
 				if err != nil {
-					return err
+					return packetsRead, err
 				}
 
-				c.leftoverAudio = append(c.leftoverAudio, frame.Data()...)
-
-				// if first audio frame since play, store its offset
-				if c.needsFirstAudioFrameOffset {
-					var err error
-					c.firstAudioFrameOffsetOnPlay, err = frame.PresentationOffset()
-					if err != nil {
-						return err
-					}
-					c.needsFirstAudioFrameOffset = false
+				presOffset, err := frame.PresentationOffset()
+				if err != nil {
+					return packetsRead, err
 				}
-
-				return nil
+				c.noLockQueueDecodedAudio(frame.Data(), presOffset)
+				return packetsRead, nil
 			}
 		default:
 			// ignore other packets (they exist and I don't know what they are)
 		}
 	}
+	return packetsRead, nil
 }
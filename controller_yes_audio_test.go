@@ -0,0 +1,186 @@
+package avebi
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+// fakeAudioPlayer plays audio in real time according to nowFunc, reading
+// ahead from its source up to the buffer size like ebitengine does.
+type fakeAudioPlayer struct {
+	src        io.Reader
+	bufferSize time.Duration
+	playing    bool
+	played     time.Duration // before the last Play() while playing
+	playStart  time.Time
+	served     int // bytes
+}
+
+func (p *fakeAudioPlayer) Play() {
+	if !p.playing {
+		p.playing = true
+		p.playStart = nowFunc()
+	}
+}
+
+func (p *fakeAudioPlayer) Pause() {
+	p.played = p.Position()
+	p.playing = false
+}
+
+func (p *fakeAudioPlayer) Close() error {
+	p.Pause()
+	return nil
+}
+
+func (p *fakeAudioPlayer) Position() time.Duration {
+	position := p.played
+	if p.playing {
+		position += nowFunc().Sub(p.playStart)
+	}
+	// can't play audio that wasn't served yet
+	return min(position, audioBytesToDuration(p.served, audioTestSampleRate))
+}
+
+func (*fakeAudioPlayer) SetVolume(_ float64) {}
+
+func (p *fakeAudioPlayer) SetBufferSize(bufferSize time.Duration) {
+	p.bufferSize = bufferSize
+}
+
+const (
+	audioTestSampleRate    = 48000
+	audioTestFrameDuration = 40 * time.Millisecond
+	audioTestChunk         = 10 * time.Millisecond // decoded and read at once
+)
+
+// newAudioTestController returns a paused controller with an audio clock,
+// but without media: audio is decoded by fillAudio() instead.
+func newAudioTestController(t *testing.T) (*videoWithAudioController, *[]*fakeAudioPlayer) {
+	var players []*fakeAudioPlayer
+	prev := newAudioOutput
+	newAudioOutput = func(src io.Reader) (audioOutput, error) {
+		player := &fakeAudioPlayer{src: src}
+		players = append(players, player)
+		return player, nil
+	}
+	t.Cleanup(func() { newAudioOutput = prev })
+
+	c := &videoWithAudioController{
+		duration:                   time.Hour,
+		frameDuration:              audioTestFrameDuration,
+		state:                      Paused,
+		volume:                     1.0,
+		audioBufferSize:            100 * time.Millisecond,
+		sampleRate:                 audioTestSampleRate,
+		maxPacketsPerRead:          -1,
+		needsFirstAudioFrameOffset: true,
+	}
+	return c, &players
+}
+
+// fillAudio makes the player read ahead up to its buffer size, decoding
+// audio chunks on demand as internalReadAudioFrame() would.
+func fillAudio(t *testing.T, c *videoWithAudioController, p *fakeAudioPlayer) {
+	chunkBytes := durationToAudioBytes(audioTestChunk, audioTestSampleRate)
+	buffer := make([]byte, chunkBytes)
+	for audioBytesToDuration(p.served, audioTestSampleRate)-p.Position() < p.bufferSize {
+		c.mutex.Lock()
+		if len(c.leftoverAudio) < chunkBytes {
+			c.noLockQueueDecodedAudio(make([]byte, chunkBytes), c.audioHistoryEnd)
+		}
+		c.mutex.Unlock()
+
+		n, err := p.src.Read(buffer)
+		if err != nil {
+			t.Fatalf("Read() failed: %v", err)
+		}
+		p.served += n
+	}
+}
+
+func TestPausePositionContinuity(t *testing.T) {
+	clock := newFakeClock(t)
+	c, players := newAudioTestController(t)
+
+	const tick = 5 * time.Millisecond
+	var last time.Duration
+	step := func(expectedAdvance time.Duration) {
+		clock.advance(tick)
+		if c.state == Playing {
+			fillAudio(t, c, (*players)[len(*players)-1])
+		}
+		pos, err := c.Position()
+		if err != nil {
+			t.Fatalf("Position() failed: %v", err)
+		}
+		if jump := pos - last - expectedAdvance; jump.Abs() > audioTestFrameDuration {
+			t.Fatalf("position jumped from %v to %v (expected advance %v)", last, pos, expectedAdvance)
+		}
+		last = pos
+	}
+
+	for cycle := 0; cycle < 5; cycle++ {
+		if err := c.Play(); err != nil {
+			t.Fatalf("Play() failed: %v", err)
+		}
+		if len(*players) != cycle+1 {
+			t.Fatalf("expected a new audio player on each play, got %d players", len(*players))
+		}
+		for i := 0; i < 50+7*cycle; i++ {
+			step(tick)
+		}
+
+		if err := c.Pause(); err != nil {
+			t.Fatalf("Pause() failed: %v", err)
+		}
+		if len(c.leftoverAudio) == 0 {
+			t.Fatalf("cycle %d: the audio buffered by the player was not restored", cycle)
+		}
+		for i := 0; i < 20; i++ {
+			step(0)
+		}
+	}
+
+	// the served audio must also continue where it was paused, except
+	// for the rounding down to whole samples
+	restoredOffset := c.leftoverAudioOffset
+	if diff := last - restoredOffset; diff < 0 || diff > time.Millisecond {
+		t.Fatalf("audio resumes at %v, but the position was paused at %v", restoredOffset, last)
+	}
+}
+
+func TestBackgroundDecodingDoesNotBlockFrames(t *testing.T) {
+	clock := newFakeClock(t)
+	c, players := newAudioTestController(t)
+	c.backgroundDecoding = true
+	if err := c.Play(); err != nil {
+		t.Fatalf("Play() failed: %v", err)
+	}
+	clock.advance(audioTestChunk)
+	fillAudio(t, c, (*players)[0])
+
+	// simulate Read() decoding without holding c.mutex
+	c.decodeMutex.Lock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, _, err := c.CurrentVideoFrame(); err != nil {
+			t.Errorf("CurrentVideoFrame() failed: %v", err)
+		}
+		if _, err := c.Position(); err != nil {
+			t.Errorf("Position() failed: %v", err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("CurrentVideoFrame() waited for the ongoing decoding")
+	}
+	c.decodeMutex.Unlock()
+
+	if c.mediaLocked {
+		t.Fatal("mediaLocked was left set after unlocking")
+	}
+}
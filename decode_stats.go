@@ -0,0 +1,92 @@
+package avebi
+
+import (
+	"sync"
+	"time"
+
+	"github.com/erparts/reisen"
+)
+
+// Video decoding statistics, see [Player.DecodeStats]().
+type DecodeStats struct {
+	// Amount of video frames decoded since the player was created.
+	FrameCount int
+
+	// Average and maximum time spent decoding a video frame, including
+	// the conversion to RGBA and any packets that didn't produce a frame
+	// on their own (e.g. due to codec delay).
+	AverageDecodeTime time.Duration
+	MaxDecodeTime     time.Duration
+
+	// Average amount of video packets sent to the decoder per decoded
+	// frame. Values considerably above 1 indicate frames being skipped
+	// by the decoder.
+	AveragePacketsPerFrame float64
+}
+
+// Implemented by the package controllers to report their decode timings.
+// Custom [VideoController] implementations don't need to implement it.
+type decodeStatsReporter interface {
+	DecodeStats() DecodeStats
+}
+
+// Accumulates decode timings. It has its own mutex, as live streams
+// decode frames on a separate goroutine.
+type decodeStatsCollector struct {
+	mutex     sync.Mutex
+	frames    int
+	packets   int
+	total     time.Duration
+	max       time.Duration
+	frameTime time.Duration // accumulated for the frame being decoded
+}
+
+// Calls stream.ReadVideoFrame(), recording how long it takes.
+func (s *decodeStatsCollector) readVideoFrame(stream *reisen.VideoStream) (*reisen.VideoFrame, bool, error) {
+	start := time.Now()
+	frame, frameFound, err := stream.ReadVideoFrame()
+	elapsed := time.Since(start)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.packets += 1
+	s.frameTime += elapsed
+	if frame != nil {
+		s.frames += 1
+		s.total += s.frameTime
+		s.max = max(s.max, s.frameTime)
+		s.frameTime = 0
+	}
+	return frame, frameFound, err
+}
+
+func (s *decodeStatsCollector) stats() DecodeStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.frames == 0 {
+		return DecodeStats{}
+	}
+	return DecodeStats{
+		FrameCount:             s.frames,
+		AverageDecodeTime:      s.total / time.Duration(s.frames),
+		MaxDecodeTime:          s.max,
+		AveragePacketsPerFrame: float64(s.packets) / float64(s.frames),
+	}
+}
+
+// Returns timing statistics for the video frames decoded since the player
+// was created, which can help diagnosing stuttering: if the average decode
+// time is close to the frame duration, the machine can't keep up with the
+// video, and lowering the resolution (see [PlayerOptions].DecodeScale) can
+// help. Only the playback decoder is measured, not the separate decoders
+// used by methods like [Player.CachedFrameAt](). For audio-only media and
+// custom controllers (see [NewPlayerWithController]()), the stats are always
+// zero.
+//
+// The stats are collected continuously, but the overhead is negligible.
+func (p *Player) DecodeStats() DecodeStats {
+	if reporter, ok := p.controller.(decodeStatsReporter); ok {
+		return reporter.DecodeStats()
+	}
+	return DecodeStats{}
+}
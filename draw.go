@@ -15,7 +15,17 @@ import "github.com/hajimehoshi/ebiten/v2"
 //	frame, err := videoPlayer.CurrentFrame()
 //	if err != nil { /* handle error */ }
 //	avebi.Draw(screen, frame)
+//
+// Drawing doesn't modify the frame, so the same frame can be drawn into
+// multiple viewports (e.g. a main view and a thumbnail) without decoding
+// it again. Use sub-images of the screen to draw into specific regions:
+//
+//	avebi.Draw(screen.SubImage(mainRect).(*ebiten.Image), frame)
+//	avebi.Draw(screen.SubImage(thumbRect).(*ebiten.Image), frame)
 func Draw(viewport, frame *ebiten.Image) {
+	if frame == nil { // e.g. audio-only media
+		return
+	}
 	geom, filter := CalcProjection(viewport, frame)
 	var opts ebiten.DrawImageOptions
 	opts.GeoM = geom
@@ -57,3 +67,32 @@ func CalcProjection(viewport, frame *ebiten.Image) (ebiten.GeoM, ebiten.Filter)
 	}
 	return geom, filter
 }
+
+// Draws the frame last returned by [Player.CurrentFrame]() into the given
+// viewport, projected like [Draw](), but with the given options for the
+// rest of the drawing parameters, like the blend mode and color scale.
+// The GeoM and Filter are computed with [CalcProjection](). Any GeoM in
+// opts is applied after the projection, in viewport coordinates, so
+// additional effects like shaking or zooming can be composed on top,
+// while the Filter in opts is ignored. The given options are not
+// modified, and nil options are equivalent to [Draw]().
+//
+// This doesn't decode new frames, so [Player.CurrentFrame]() must still
+// be called on each update. Nothing is drawn for audio-only media. For
+// custom shaders, use [CalcProjection]() with [ebiten.Image.DrawRectShader]()
+// instead.
+func (p *Player) Draw(viewport *ebiten.Image, opts *ebiten.DrawImageOptions) {
+	if p.currentFrame == nil { // audio-only media
+		return
+	}
+	frame := p.syncedFrame()
+	geom, filter := CalcProjection(viewport, frame)
+	var drawOpts ebiten.DrawImageOptions
+	if opts != nil {
+		drawOpts = *opts
+		geom.Concat(opts.GeoM)
+	}
+	drawOpts.GeoM = geom
+	drawOpts.Filter = filter
+	viewport.DrawImage(frame, &drawOpts)
+}
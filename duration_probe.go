@@ -0,0 +1,119 @@
+package avebi
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/erparts/reisen"
+)
+
+// Frames are decoded at this tiny resolution while probing the duration,
+// as only their timestamps matter and the RGBA conversion is not free.
+const probeDecodeSize = 2
+
+// Decodes the whole main stream of the given source and returns the end
+// of its last frame. The video stream is used if there's any, otherwise
+// the audio stream is used.
+func probeDuration(source string) (time.Duration, error) {
+	media, err := reisen.NewMedia(source)
+	if err != nil {
+		return 0, err
+	}
+	defer media.Close()
+
+	var stream reisen.Stream
+	var readFrameEnd func() (time.Duration, bool, error) // end, frame found, error
+	if videoStreams := media.VideoStreams(); len(videoStreams) > 0 {
+		video := videoStreams[0]
+		frameDuration := nominalFrameDuration(video)
+		stream = video
+		readFrameEnd = func() (time.Duration, bool, error) {
+			frame, _, err := video.ReadVideoFrame()
+			if err != nil || frame == nil {
+				return 0, false, err
+			}
+			presOffset, err := frame.PresentationOffset()
+			return presOffset + frameDuration, true, err
+		}
+	} else if audioStreams := media.AudioStreams(); len(audioStreams) > 0 {
+		audio := audioStreams[0]
+		stream = audio
+		readFrameEnd = func() (time.Duration, bool, error) {
+			frame, _, err := audio.ReadAudioFrame()
+			if err != nil || frame == nil {
+				return 0, false, err
+			}
+			presOffset, err := frame.PresentationOffset()
+			return presOffset + audioBytesToDuration(len(frame.Data()), audio.SampleRate()), true, err
+		}
+	} else {
+		return 0, ErrNoVideo
+	}
+
+	err = media.OpenDecode()
+	if err != nil {
+		return 0, err
+	}
+	defer media.CloseDecode()
+	if video, isVideo := stream.(*reisen.VideoStream); isVideo {
+		err = video.OpenDecode(probeDecodeSize, probeDecodeSize, reisen.InterpolationPoint)
+	} else {
+		err = stream.Open()
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer stream.Close()
+
+	var lastEnd time.Duration
+	for {
+		packet, packetFound, err := media.ReadPacket()
+		if err != nil {
+			return 0, err
+		}
+		if !packetFound {
+			return lastEnd, nil
+		}
+		if packet.StreamIndex() != stream.Index() {
+			continue
+		}
+
+		frameEnd, frameFound, err := readFrameEnd()
+		if err != nil {
+			return 0, err
+		}
+		if frameFound {
+			// presentation order is not decode order, so keep the max
+			lastEnd = max(lastEnd, frameEnd)
+		}
+	}
+}
+
+// Returns the video duration measured by decoding the whole video and
+// taking the end of its last frame, which can differ slightly from the
+// duration declared by the container and returned by [Player.Duration]().
+// This is useful when precise values are needed near the end, like for
+// progress bars or loop points. For audio-only media, the audio is used.
+//
+// This is very expensive, as the whole video must be decoded, but it's done
+// with a separate decoder, so the playback is not affected, and the result
+// is cached. Notice that the player itself still uses [Player.Duration]()
+// internally. This is only available for players created from a file or URL.
+func (p *Player) DurationAccurate() (time.Duration, error) {
+	if p.closed {
+		return 0, ErrPlayerClosed
+	}
+	if p.accurateDuration > 0 {
+		return p.accurateDuration, nil
+	}
+	if p.source == "" || p.IsLive() {
+		return 0, fmt.Errorf("accurate durations: %w", ErrNoSource)
+	}
+
+	duration, err := probeDuration(p.source)
+	if err != nil {
+		return 0, err
+	}
+	p.accurateDuration = duration
+	return duration, nil
+}
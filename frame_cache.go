@@ -0,0 +1,312 @@
+package avebi
+
+import (
+	"container/list"
+	"fmt"
+	"time"
+
+	"github.com/erparts/reisen"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Default amount of frames kept by the [Player.CachedFrameAt]() cache.
+const defaultFrameCacheSize = 16
+
+// Decodes frames at arbitrary positions with its own decoder, so the
+// playback of the player is not affected.
+type framePeeker struct {
+	media         *reisen.Media
+	stream        *reisen.VideoStream
+	frameDuration time.Duration
+}
+
+// Opens the given source for decoding frames at the given resolution.
+func newFramePeeker(source string, width, height int) (*framePeeker, error) {
+	media, err := reisen.NewMedia(source)
+	if err != nil {
+		return nil, err
+	}
+
+	videoStreams := media.VideoStreams()
+	if len(videoStreams) == 0 {
+		media.Close()
+		return nil, ErrNoVideo
+	}
+	stream := videoStreams[0]
+	frameDuration := nominalFrameDuration(stream)
+
+	err = media.OpenDecode()
+	if err != nil {
+		media.Close()
+		return nil, err
+	}
+	err = openVideoDecode(stream, width, height)
+	if err != nil {
+		_ = media.CloseDecode()
+		media.Close()
+		return nil, err
+	}
+
+	return &framePeeker{media: media, stream: stream, frameDuration: frameDuration}, nil
+}
+
+// Returns the frame presented at the given position, or the last
+// frame of the video if the position is past the end.
+func (fp *framePeeker) frameAt(at time.Duration) (*reisen.VideoFrame, error) {
+	err := fp.stream.Rewind(at)
+	if err != nil {
+		return nil, err
+	}
+
+	// the rewind lands on the previous keyframe, decode until the target
+	var lastFrame *reisen.VideoFrame
+	for {
+		frame, err := fp.nextFrame()
+		if err != nil {
+			return nil, err
+		}
+		if frame == nil {
+			return lastFrame, nil
+		}
+		lastFrame = frame
+		presOffset, err := frame.PresentationOffset()
+		if err != nil {
+			return nil, err
+		}
+		if presOffset+fp.frameDuration > at {
+			return lastFrame, nil
+		}
+	}
+}
+
+// Decodes the next video frame. A nil frame means the end of the stream.
+func (fp *framePeeker) nextFrame() (*reisen.VideoFrame, error) {
+	for {
+		packet, packetFound, err := fp.media.ReadPacket()
+		if err != nil {
+			return nil, err
+		}
+		if !packetFound {
+			return nil, nil
+		}
+		if packet.Type() != reisen.StreamVideo || packet.StreamIndex() != fp.stream.Index() {
+			continue
+		}
+
+		frame, _, err := fp.stream.ReadVideoFrame()
+		if err != nil {
+			return nil, err
+		}
+		if frame != nil {
+			return frame, nil
+		}
+	}
+}
+
+func (fp *framePeeker) close() error {
+	err := fp.stream.Close()
+	if err != nil {
+		return err
+	}
+	err = fp.media.CloseDecode()
+	if err != nil {
+		return err
+	}
+	fp.media.Close()
+	return nil
+}
+
+// A least recently used cache of frame images, keyed by quantized position.
+type frameCache struct {
+	capacity int
+	entries  map[time.Duration]*list.Element
+	order    *list.List // most recently used at the front
+}
+
+type frameCacheEntry struct {
+	key   time.Duration
+	image *ebiten.Image
+}
+
+func (c *frameCache) get(key time.Duration) *ebiten.Image {
+	elem, found := c.entries[key]
+	if !found {
+		return nil
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*frameCacheEntry).image
+}
+
+func (c *frameCache) add(key time.Duration, image *ebiten.Image) {
+	if c.entries == nil {
+		c.entries = make(map[time.Duration]*list.Element)
+		c.order = list.New()
+	}
+	c.entries[key] = c.order.PushFront(&frameCacheEntry{key: key, image: image})
+	c.evict()
+}
+
+func (c *frameCache) setCapacity(capacity int) {
+	c.capacity = capacity
+	c.evict()
+}
+
+func (c *frameCache) clear() {
+	for c.order != nil && c.order.Len() > 0 {
+		c.removeOldest()
+	}
+}
+
+func (c *frameCache) evict() {
+	for c.order != nil && c.order.Len() > c.capacity {
+		c.removeOldest()
+	}
+}
+
+func (c *frameCache) removeOldest() {
+	entry := c.order.Remove(c.order.Back()).(*frameCacheEntry)
+	delete(c.entries, entry.key)
+	entry.image.Deallocate()
+}
+
+// Returns an image with the video frame at the given position. Frames are
+// decoded with a separate decoder, so the playback is not affected, and
+// cached by position (quantized to the frame duration) so repeated requests
+// for the same positions are cheap. This is useful for timeline previews
+// and similar UI elements that are redrawn often.
+//
+// The returned images are owned by the cache: they remain valid and
+// unmodified until evicted, which can happen on later calls once more
+// than [Player.SetFrameCacheSize]() different positions are requested.
+// Frame processing and chroma keying are not applied to these frames.
+//
+// This is only available for players created from a file or URL.
+func (p *Player) CachedFrameAt(at time.Duration) (*ebiten.Image, error) {
+	if p.closed {
+		return nil, ErrPlayerClosed
+	}
+	frameDuration := max(p.frameDuration, time.Millisecond)
+	key := (max(at, 0) / frameDuration) * frameDuration
+	if image := p.frameCache.get(key); image != nil {
+		return image, nil
+	}
+
+	if p.source == "" {
+		return nil, fmt.Errorf("cached frames: %w", ErrNoSource)
+	}
+	if err := p.ensurePeeker(); err != nil {
+		return nil, err
+	}
+
+	frame, err := p.peeker.frameAt(key)
+	if err != nil {
+		return nil, err
+	}
+	if frame == nil {
+		return nil, ErrNoFrame
+	}
+	pixels := frame.Data()
+	if err := p.checkFrameData(pixels); err != nil {
+		return nil, err
+	}
+	width, height := p.Resolution()
+	image := ebiten.NewImage(width, height)
+	image.WritePixels(pixels)
+	if p.frameCache.capacity > 0 {
+		p.frameCache.add(key, image)
+	}
+	return image, nil
+}
+
+// Creates the frame peeker if it doesn't exist yet.
+func (p *Player) ensurePeeker() error {
+	if p.peeker != nil {
+		return nil
+	}
+	width, height := p.Resolution()
+	peeker, err := newFramePeeker(p.source, width, height)
+	if err != nil {
+		return err
+	}
+	if p.frameDurationOverride > 0 {
+		peeker.frameDuration = p.frameDurationOverride
+	}
+	p.peeker = peeker
+	return nil
+}
+
+// Sets the maximum amount of frames kept by the [Player.CachedFrameAt]()
+// cache. The default is 16. Each frame uses a full resolution image, so
+// large values can consume considerable amounts of GPU memory. Setting 0
+// disables caching: frames are decoded on each request, and the returned
+// images are owned by the caller.
+func (p *Player) SetFrameCacheSize(size int) {
+	p.frameCache.setCapacity(max(size, 0))
+}
+
+// Releases the frame cache and its decoder, if any.
+func (p *Player) closeFrameCache() error {
+	p.frameCache.clear()
+	if p.peeker == nil {
+		return nil
+	}
+	err := p.peeker.close()
+	p.peeker = nil
+	return err
+}
+
+// Returns count thumbnails of the video, taken at evenly spaced positions
+// from the start (position i * duration / count), and downscaled to the
+// given size. This is useful for the filmstrips shown by video editors
+// and scrubbing UIs.
+//
+// For speed, each thumbnail is taken from the keyframe closest before its
+// position instead of decoding up to the exact frame, so thumbnails can be
+// slightly earlier than expected. The frames are decoded with a transient
+// decoder, so the playback is not affected, and the returned images are
+// owned by the caller. This is only available for players created from a
+// file or URL.
+func (p *Player) ThumbnailStrip(count int, w, h int) ([]*ebiten.Image, error) {
+	if p.closed {
+		return nil, ErrPlayerClosed
+	}
+	if p.source == "" || p.IsLive() {
+		return nil, fmt.Errorf("thumbnails: %w", ErrNoSource)
+	}
+	if count <= 0 || w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("invalid thumbnail strip parameters (count %d, size %dx%d)", count, w, h)
+	}
+
+	peeker, err := newFramePeeker(p.source, w, h)
+	if err != nil {
+		return nil, err
+	}
+	defer peeker.close()
+
+	duration := p.controller.Duration()
+	thumbnails := make([]*ebiten.Image, 0, count)
+	for i := range count {
+		position := (duration * time.Duration(i)) / time.Duration(count)
+		err := peeker.stream.Rewind(position)
+		if err != nil {
+			return nil, err
+		}
+		frame, err := peeker.nextFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		thumbnail := ebiten.NewImage(w, h)
+		switch {
+		case frame != nil:
+			thumbnail.WritePixels(frame.Data())
+		case len(thumbnails) > 0: // nothing to decode past the end, repeat the previous one
+			thumbnail.DrawImage(thumbnails[len(thumbnails)-1], nil)
+		default:
+			thumbnail.Deallocate()
+			return nil, ErrNoFrame
+		}
+		thumbnails = append(thumbnails, thumbnail)
+	}
+	return thumbnails, nil
+}
@@ -0,0 +1,118 @@
+package avebi
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Returned when trying to export a frame while the player is not showing
+// any video frame (e.g. the player is stopped or hasn't started yet).
+var ErrNoFrame = errors.New("no video frame available (player stopped or not started)")
+
+// Like [Player.CurrentFrame](), but writes the frame into the given image
+// instead of the player's own one, which allows managing frame images
+// directly, e.g. for double buffering. The image must have the same size
+// as the [Player.Resolution](), and sub-images are allowed. Frame processing
+// is applied as usual. The player's own image is only written if it's later
+// requested with CurrentFrame() or drawn with [Player.Draw](), so each new
+// frame is uploaded only once.
+//
+// The pixels are only written if the frame has changed since the previous
+// call, which is reported through the returned bool, so unchanged frames
+// don't cost any upload. Before the first frame, the image is left untouched.
+// Notice that this tracks changes per player, not per image: when alternating
+// between multiple images, an unchanged frame means that the image written
+// on the previous call is still up to date. Audio-only media never reports
+// changes.
+func (p *Player) CurrentFrameInto(dst *ebiten.Image) (bool, error) {
+	if p.closed {
+		return false, ErrPlayerClosed
+	}
+	if p.currentFrame == nil { // audio-only media
+		_, err := p.updateFrame()
+		return false, err
+	}
+	width, height := p.Resolution()
+	if bounds := dst.Bounds(); bounds.Dx() != width || bounds.Dy() != height {
+		return false, fmt.Errorf("destination image size %dx%d doesn't match the video resolution %dx%d", bounds.Dx(), bounds.Dy(), width, height)
+	}
+
+	prevVersion := p.frameVersion
+	p.frameTarget = dst
+	_, err := p.updateFrame()
+	p.frameTarget = nil
+	if err != nil {
+		return false, err
+	}
+	if p.frameVersion == p.lastIntoVersion {
+		return false, nil
+	}
+	p.lastIntoVersion = p.frameVersion
+
+	// the frame might have changed outside this call, e.g. while seeking,
+	// in which case it was written to the player's own image instead
+	writtenToDst := p.frameVersion != prevVersion && p.currentFrameStale
+	switch {
+	case p.onBlackFrame || p.framePixels == nil:
+		dst.Fill(p.getPlaceholderColor())
+	case !writtenToDst:
+		dst.WritePixels(p.framePixels)
+	}
+	return true, nil
+}
+
+// Saves the frame currently shown by the player to the given path, encoded
+// as PNG or JPEG depending on the file extension (".png", ".jpg", ".jpeg").
+// The pixels are taken from the decoded frame data (after frame processing),
+// so no GPU read back is required.
+//
+// Notice that the frame is the one obtained on the last [Player.CurrentFrame]()
+// call, not necessarily the one at the current [Player.Position](). If no video
+// frame is being shown, [ErrNoFrame] is returned instead of saving a black image.
+func (p *Player) SaveFrame(path string) error {
+	if p.closed {
+		return ErrPlayerClosed
+	}
+	if p.onBlackFrame || p.framePixels == nil {
+		return ErrNoFrame
+	}
+
+	var encode func(*os.File, image.Image) error
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".png":
+		encode = func(file *os.File, img image.Image) error { return png.Encode(file, img) }
+	case ".jpg", ".jpeg":
+		encode = func(file *os.File, img image.Image) error {
+			return jpeg.Encode(file, img, &jpeg.Options{Quality: 90})
+		}
+	default:
+		return fmt.Errorf("unsupported image file extension '%s'", ext)
+	}
+
+	// image.RGBA is also alpha-premultiplied, so we can use the data directly
+	width, height := p.Resolution()
+	img := &image.RGBA{
+		Pix:    p.framePixels,
+		Stride: width * frameBytesPerPixel,
+		Rect:   image.Rect(0, 0, width, height),
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = encode(file, img)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,175 @@
+package avebi
+
+import (
+	"image/color"
+	"math"
+)
+
+// The pixel layout of decoded video frames. See [Player.PixelFormat]().
+type PixelFormat uint8
+
+const (
+	// 4 bytes per pixel (red, green, blue, alpha), row by row, without
+	// padding. The alpha channel is fully opaque unless modified by
+	// frame processing.
+	PixelFormatRGBA PixelFormat = iota
+)
+
+// Returns a string representation of the pixel format ("RGBA", "<invalid>").
+func (f PixelFormat) String() string {
+	switch f {
+	case PixelFormatRGBA:
+		return "RGBA"
+	default:
+		return "<invalid>"
+	}
+}
+
+// Video frames are always [PixelFormatRGBA]. reisen converts all
+// video streams to this format internally.
+const frameBytesPerPixel = 4
+
+// Configuration for chroma keying (green screen removal).
+type chromaKey struct {
+	enabled   bool
+	r, g, b   float64 // key color, normalized to [0, 1]
+	tolerance float64 // normalized distance in [0, 1]
+
+	// per channel tolerances, see ChromaKeyOptions. tolerance is
+	// ignored when perChannel is true
+	perChannel bool
+	tolerances [3]float64
+
+	// spill suppression, see ChromaKeyOptions
+	spill        float64
+	spillChannel int // dominant channel of the key color
+}
+
+func newChromaKey(key color.Color, tolerance float64) chromaKey {
+	if key == nil {
+		return chromaKey{}
+	}
+	r, g, b, _ := key.RGBA()
+	return chromaKey{
+		enabled:   true,
+		r:         float64(r) / 0xFFFF,
+		g:         float64(g) / 0xFFFF,
+		b:         float64(b) / 0xFFFF,
+		tolerance: min(max(tolerance, 0), 1),
+	}
+}
+
+func newChromaKeyWithOptions(key color.Color, opts ChromaKeyOptions) chromaKey {
+	k := newChromaKey(key, 0)
+	if !k.enabled {
+		return k
+	}
+
+	k.perChannel = true
+	k.tolerances[0] = min(max(opts.RedTolerance, 0), 1)
+	k.tolerances[1] = min(max(opts.GreenTolerance, 0), 1)
+	k.tolerances[2] = min(max(opts.BlueTolerance, 0), 1)
+	k.spill = min(max(opts.SpillSuppression, 0), 1)
+	if k.g >= k.r && k.g >= k.b {
+		k.spillChannel = 1
+	} else if k.b >= k.r {
+		k.spillChannel = 2
+	}
+	return k
+}
+
+// Makes pixels close enough to the key color fully transparent. Since
+// ebitengine uses premultiplied alpha, transparent pixels are fully zeroed.
+func (k *chromaKey) apply(pixels []byte) {
+	if !k.enabled {
+		return
+	}
+
+	// we compare squared distances to avoid the square roots
+	maxDistSq := k.tolerance * k.tolerance * 3.0
+	for i := 0; i+frameBytesPerPixel <= len(pixels); i += frameBytesPerPixel {
+		dr := float64(pixels[i+0])/255.0 - k.r
+		dg := float64(pixels[i+1])/255.0 - k.g
+		db := float64(pixels[i+2])/255.0 - k.b
+		var keyed bool
+		if k.perChannel {
+			keyed = math.Abs(dr) <= k.tolerances[0] && math.Abs(dg) <= k.tolerances[1] && math.Abs(db) <= k.tolerances[2]
+		} else {
+			keyed = dr*dr+dg*dg+db*db <= maxDistSq
+		}
+		if keyed {
+			pixels[i+0], pixels[i+1], pixels[i+2], pixels[i+3] = 0, 0, 0, 0
+		} else if k.spill > 0 {
+			k.suppressSpill(pixels[i : i+3])
+		}
+	}
+}
+
+// Pulls the dominant key channel of the given RGB pixel towards the
+// average of the other two channels, if it exceeds it.
+func (k *chromaKey) suppressSpill(rgb []byte) {
+	other1, other2 := rgb[(k.spillChannel+1)%3], rgb[(k.spillChannel+2)%3]
+	limit := (float64(other1) + float64(other2)) / 2.0
+	value := float64(rgb[k.spillChannel])
+	if value > limit {
+		rgb[k.spillChannel] = byte(math.Round(value - (value-limit)*k.spill))
+	}
+}
+
+// Configuration for brightness, contrast and gamma adjustments. The
+// adjustments are precomputed into a lookup table shared by the red,
+// green and blue channels.
+type colorAdjust struct {
+	enabled bool
+	lut     [256]byte
+}
+
+func newColorAdjust(brightness, contrast, gamma float64) colorAdjust {
+	if brightness == 0 && contrast == 1 && gamma == 1 {
+		return colorAdjust{}
+	}
+
+	contrast = max(contrast, 0)
+	invGamma := 1.0 / max(gamma, 0.01)
+	adjust := colorAdjust{enabled: true}
+	for i := range adjust.lut {
+		value := float64(i) / 255.0
+		value = (value-0.5)*contrast + 0.5 + brightness
+		value = math.Pow(min(max(value, 0), 1), invGamma)
+		adjust.lut[i] = byte(math.Round(value * 255.0))
+	}
+	return adjust
+}
+
+// Applies the color adjustments to the RGB channels of the given pixels.
+// Fully transparent pixels are left untouched, so they remain valid
+// premultiplied alpha values.
+func (a *colorAdjust) apply(pixels []byte) {
+	if !a.enabled {
+		return
+	}
+
+	for i := 0; i+frameBytesPerPixel <= len(pixels); i += frameBytesPerPixel {
+		if pixels[i+3] == 0 {
+			continue
+		}
+		pixels[i+0] = a.lut[pixels[i+0]]
+		pixels[i+1] = a.lut[pixels[i+1]]
+		pixels[i+2] = a.lut[pixels[i+2]]
+	}
+}
+
+// Converts the given pixels from straight to premultiplied alpha, in place.
+// Opaque pixels are left untouched, so fully opaque frames are cheap.
+func premultiplyAlpha(pixels []byte) {
+	for i := 0; i+frameBytesPerPixel <= len(pixels); i += frameBytesPerPixel {
+		alpha := uint32(pixels[i+3])
+		if alpha == 255 {
+			continue
+		}
+		// (x*a + 127)/255 rounds to the nearest integer
+		pixels[i+0] = byte((uint32(pixels[i+0])*alpha + 127) / 255)
+		pixels[i+1] = byte((uint32(pixels[i+1])*alpha + 127) / 255)
+		pixels[i+2] = byte((uint32(pixels[i+2])*alpha + 127) / 255)
+	}
+}
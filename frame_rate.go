@@ -0,0 +1,187 @@
+package avebi
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+
+	"github.com/erparts/reisen"
+)
+
+// Frame rate assumed for video streams reporting a zero or negative
+// frame rate, which happens with some broken files.
+const fallbackFrameRate = 30
+
+// A frame rate as an exact fraction of frames per second. The zero
+// value means that the frame rate is unknown.
+type frameRate struct {
+	num, denom int
+}
+
+// Returns the nominal frame duration of the given video stream, falling
+// back to fallbackFrameRate if the reported frame rate is not valid.
+// See hasValidFrameRate().
+func nominalFrameDuration(stream *reisen.VideoStream) time.Duration {
+	rate := nominalFrameRate(stream)
+	return (time.Second * time.Duration(rate.denom)) / time.Duration(rate.num)
+}
+
+// Returns the frame rate reported by the given video stream, or
+// fallbackFrameRate if it's not valid. See hasValidFrameRate().
+func nominalFrameRate(stream *reisen.VideoStream) frameRate {
+	if !hasValidFrameRate(stream) {
+		return frameRate{fallbackFrameRate, 1}
+	}
+	frNum, frDenom := stream.FrameRate()
+	return frameRate{frNum, frDenom}
+}
+
+// Returns whether the frame rate reported by the given video stream can be
+// used to compute a frame duration, which requires a positive rate that is
+// not absurdly high (the duration must be at least 1ns).
+func hasValidFrameRate(stream *reisen.VideoStream) bool {
+	frNum, frDenom := stream.FrameRate()
+	if frNum <= 0 || frDenom <= 0 {
+		return false
+	}
+	return (time.Second*time.Duration(frDenom))/time.Duration(frNum) > 0
+}
+
+// Implemented by the controllers that rely on a nominal frame duration, so
+// it can be overridden for files with bogus frame rate metadata. See
+// [Player.SetFrameRateOverride]().
+type frameDurationOverrider interface {
+	setFrameDuration(frameDuration time.Duration)
+}
+
+// Overrides the frame rate detected from the file metadata with num/denom
+// frames per second (e.g. 30000/1001 for 29.97fps). Some muxers write bogus
+// frame rates (like 90000fps), which make the nominal frame duration tiny
+// and break frame pacing, position snapping, keyframe navigation and the
+// frame cache. This is a workaround for such files until they can be
+// re-encoded. Passing 0, 0 removes the override. Audio-only media doesn't
+// have frames, so the override is ignored for it.
+//
+// The override applies to the player and its decoders from the next frame,
+// and it's kept when switching sources with [Player.SwitchSource](). It has
+// no effect on live streams, which are paced by the frame timestamps.
+func (p *Player) SetFrameRateOverride(num, denom int) error {
+	if p.closed {
+		return ErrPlayerClosed
+	}
+	if p.currentFrame == nil { // audio-only media
+		return nil
+	}
+
+	frameDuration := time.Duration(0) // no override
+	if num != 0 || denom != 0 {
+		if num <= 0 || denom <= 0 {
+			return fmt.Errorf("invalid frame rate override %d/%d", num, denom)
+		}
+		frameDuration = (time.Second * time.Duration(denom)) / time.Duration(num)
+		if frameDuration <= 0 {
+			return fmt.Errorf("frame rate override %d/%d is too high", num, denom)
+		}
+	}
+
+	if p.frameDurationOverride == 0 {
+		p.detectedFrameDuration = p.frameDuration
+	}
+	p.frameDurationOverride = frameDuration
+	p.frameRateOverride = frameRate{}
+	if frameDuration > 0 {
+		p.frameRateOverride = frameRate{num, denom}
+	}
+	p.applyFrameDuration(p.effectiveFrameDuration())
+	return nil
+}
+
+// Returns the frame duration given by the override if any, or the one
+// detected from the file metadata otherwise.
+func (p *Player) effectiveFrameDuration() time.Duration {
+	if p.frameDurationOverride > 0 {
+		return p.frameDurationOverride
+	}
+	return p.detectedFrameDuration
+}
+
+// Applies the frame duration override, if any, to a new controller for
+// the same media.
+func (p *Player) applyFrameDurationOverride(controller VideoController) {
+	if overrider, ok := controller.(frameDurationOverrider); ok && p.frameDurationOverride > 0 {
+		overrider.setFrameDuration(p.frameDurationOverride)
+	}
+}
+
+// Sets the frame duration on the player, the controller and the frame
+// peeker. Cached frames are quantized by the frame duration, so they are
+// discarded.
+func (p *Player) applyFrameDuration(frameDuration time.Duration) {
+	p.frameDuration = frameDuration
+	if overrider, ok := p.controller.(frameDurationOverrider); ok {
+		overrider.setFrameDuration(frameDuration)
+	}
+	if p.peeker != nil {
+		p.peeker.frameDuration = frameDuration
+	}
+	p.frameCache.clear()
+}
+
+// Returns the frame rate given by the override if any, or the one
+// detected from the file metadata otherwise.
+func (p *Player) effectiveFrameRate() frameRate {
+	if p.frameRateOverride.num > 0 {
+		return p.frameRateOverride
+	}
+	return p.detectedFrameRate
+}
+
+// Returns the position at which the frame with the given index starts,
+// counting from 0 at the start of the video. The position is rounded up to
+// the next nanosecond, so [Player.PositionToFrame]() maps it back to the
+// same index. Negative indices are treated as 0.
+//
+// The computation uses the exact frame rate fraction (e.g. 30000/1001),
+// including any [Player.SetFrameRateOverride](), so there's no rounding
+// drift on long videos. This assumes a constant frame rate: for variable
+// frame rate videos, the results are only approximate. Audio-only media
+// and players created from custom controllers without a frame rate
+// override return 0.
+func (p *Player) FrameToPosition(frame int) time.Duration {
+	rate := p.effectiveFrameRate()
+	if rate.num <= 0 || frame <= 0 {
+		return 0
+	}
+	// frame * denom / num seconds, rounded up
+	ns := mulDiv(uint64(frame), uint64(rate.denom)*uint64(time.Second), uint64(rate.num), true)
+	return time.Duration(ns)
+}
+
+// Returns the index of the frame presented at the given position, counting
+// from 0 at the start of the video. Negative positions are treated as 0.
+// This is the inverse of [Player.FrameToPosition](), and the same notes on
+// precision and variable frame rates apply.
+func (p *Player) PositionToFrame(position time.Duration) int {
+	rate := p.effectiveFrameRate()
+	if rate.num <= 0 || position <= 0 {
+		return 0
+	}
+	// position * num / denom seconds, rounded down
+	frame := mulDiv(uint64(position), uint64(rate.num), uint64(rate.denom)*uint64(time.Second), false)
+	return int(min(frame, math.MaxInt))
+}
+
+// Returns a * b / c without intermediate overflow, rounded up if roundUp is
+// true and down otherwise, saturating to math.MaxInt64. c must be positive.
+func mulDiv(a, b, c uint64, roundUp bool) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	if hi >= c { // the quotient doesn't fit in 64 bits
+		return math.MaxInt64
+	}
+	quo, rem := bits.Div64(hi, lo, c)
+	if roundUp && rem > 0 {
+		quo += 1
+	}
+	return min(quo, math.MaxInt64)
+}
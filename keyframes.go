@@ -0,0 +1,120 @@
+package avebi
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Returned by [Player.SeekKeyframe]() when there's no keyframe in the
+// requested direction.
+var ErrNoKeyframe = errors.New("no keyframe in the requested direction")
+
+// Returns the presentation offsets of the keyframes found before the given
+// position, in ascending order. reisen doesn't expose keyframe flags nor
+// the container index, so they are found by walking backwards from the
+// end: each rewind lands on the closest keyframe before the target, and
+// the next target is placed right before that keyframe. This only needs
+// one seek and decoded frame per keyframe.
+func (fp *framePeeker) keyframes(before time.Duration) ([]time.Duration, error) {
+	keyframes := make([]time.Duration, 0, 16) // non-nil even if empty, for caching
+	target := before
+	for target >= 0 {
+		err := fp.stream.Rewind(target)
+		if err != nil {
+			return nil, err
+		}
+		frame, err := fp.nextFrame()
+		if err != nil {
+			return nil, err
+		}
+		if frame == nil { // nothing to decode from here, e.g. target past the end
+			target -= fp.frameDuration
+			continue
+		}
+		presOffset, err := frame.PresentationOffset()
+		if err != nil {
+			return nil, err
+		}
+
+		// some containers don't land exactly before the target, so
+		// we step back further until we find an earlier keyframe
+		if len(keyframes) > 0 && presOffset >= keyframes[len(keyframes)-1] {
+			target = min(target, keyframes[len(keyframes)-1]) - fp.frameDuration
+			continue
+		}
+		keyframes = append(keyframes, presOffset)
+		target = presOffset - time.Millisecond
+	}
+
+	slices.Reverse(keyframes)
+	return keyframes, nil
+}
+
+// Returns the positions of the keyframes of the video, in ascending order.
+// Seeking with [Player.Seek]() decodes from the closest keyframe before
+// the target position, so seeking exactly to keyframes is the cheapest
+// and most predictable option, which makes this useful for snapping
+// scrubbing UIs and building seek indexes.
+//
+// The positions are the presentation offsets of the frames where seeks
+// land, found with a separate decoder, so the playback is not affected.
+// This requires one seek per keyframe, which can take a while for long
+// videos, but the result is cached. This is only available for players
+// created from a file or URL, and not for audio-only media.
+func (p *Player) Keyframes() ([]time.Duration, error) {
+	if p.closed {
+		return nil, ErrPlayerClosed
+	}
+	if p.keyframes != nil {
+		return slices.Clone(p.keyframes), nil
+	}
+	if p.source == "" || p.IsLive() {
+		return nil, fmt.Errorf("keyframes: %w", ErrNoSource)
+	}
+	if err := p.ensurePeeker(); err != nil {
+		return nil, err
+	}
+
+	keyframes, err := p.peeker.keyframes(p.controller.Duration())
+	if err != nil {
+		return nil, err
+	}
+	p.keyframes = keyframes
+	return slices.Clone(keyframes), nil
+}
+
+// Seeks to the next keyframe after the current position if forward is true,
+// or to the previous one otherwise, which allows skimming through a video
+// much faster than stepping frame by frame. Positions within half a frame
+// of a keyframe are considered to be on it, so repeated calls always move
+// to a different keyframe. If there's no keyframe in the given direction,
+// the position is left unchanged and [ErrNoKeyframe] is returned.
+//
+// The keyframes are obtained with [Player.Keyframes](), so the first call
+// can take a while, and the same limitations apply.
+func (p *Player) SeekKeyframe(forward bool) error {
+	keyframes, err := p.Keyframes()
+	if err != nil {
+		return err
+	}
+	position, err := p.Position()
+	if err != nil {
+		return err
+	}
+
+	tolerance := p.frameDuration / 2
+	if forward {
+		index, _ := slices.BinarySearch(keyframes, position+tolerance+1)
+		if index >= len(keyframes) {
+			return ErrNoKeyframe
+		}
+		return p.Seek(keyframes[index])
+	}
+	index, _ := slices.BinarySearch(keyframes, position-tolerance)
+	if index == 0 {
+		return ErrNoKeyframe
+	}
+	return p.Seek(keyframes[index-1])
+}
@@ -1,6 +1,9 @@
 package avebi
 
-import "log"
+import (
+	"log"
+	"sync/atomic"
+)
 
 var pkgLogger Logger = log.Default()
 
@@ -11,3 +14,47 @@ type Logger interface {
 func SetLogger(logger Logger) {
 	pkgLogger = logger
 }
+
+// Logs through the package logger, prefixing the messages with the
+// identifier of a player, see [Player.SetLogPrefix](). A nil logger
+// logs without prefix. Safe for concurrent use, as controllers log
+// from the audio and decoding goroutines.
+type playerLogger struct {
+	prefix atomic.Pointer[string]
+}
+
+func newPlayerLogger(prefix string) *playerLogger {
+	logger := &playerLogger{}
+	logger.setPrefix(prefix)
+	return logger
+}
+
+func (l *playerLogger) setPrefix(prefix string) {
+	l.prefix.Store(&prefix)
+}
+
+func (l *playerLogger) Printf(format string, v ...any) {
+	if l == nil {
+		pkgLogger.Printf(format, v...)
+		return
+	}
+	prefix := *l.prefix.Load()
+	if prefix == "" {
+		pkgLogger.Printf(format, v...)
+		return
+	}
+	pkgLogger.Printf("[%s] "+format, append([]any{prefix}, v...)...)
+}
+
+// Sets an identifier for the player, which is included at the start of
+// its log messages (e.g. "[camera 2] WARNING: ..."), so they can be told
+// apart when many players are open. The messages still go through the
+// logger set with [SetLogger](). An empty prefix, which is the default
+// unless [PlayerOptions].LogPrefix is set, disables it.
+func (p *Player) SetLogPrefix(prefix string) {
+	if p.logger == nil { // created with a custom controller
+		p.logger = newPlayerLogger(prefix)
+		return
+	}
+	p.logger.setPrefix(prefix)
+}
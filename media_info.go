@@ -0,0 +1,152 @@
+package avebi
+
+import (
+	"slices"
+	"time"
+
+	"github.com/erparts/reisen"
+)
+
+// Kinds of media streams. See [StreamInfo].
+type StreamKind uint8
+
+const (
+	StreamKindUnknown StreamKind = iota
+	StreamKindVideo
+	StreamKindAudio
+	StreamKindSubtitle
+	StreamKindData
+	StreamKindAttachment
+)
+
+func (k StreamKind) String() string {
+	switch k {
+	case StreamKindVideo:
+		return "video"
+	case StreamKindAudio:
+		return "audio"
+	case StreamKindSubtitle:
+		return "subtitle"
+	case StreamKindData:
+		return "data"
+	case StreamKindAttachment:
+		return "attachment"
+	default:
+		return "unknown"
+	}
+}
+
+// reisen only names video and audio streams, but reports the raw ffmpeg
+// media type (AVMediaType) for the rest.
+const (
+	avMediaTypeData       reisen.StreamType = 2
+	avMediaTypeSubtitle   reisen.StreamType = 3
+	avMediaTypeAttachment reisen.StreamType = 4
+)
+
+func streamKindOf(streamType reisen.StreamType) StreamKind {
+	switch streamType {
+	case reisen.StreamVideo:
+		return StreamKindVideo
+	case reisen.StreamAudio:
+		return StreamKindAudio
+	case avMediaTypeSubtitle:
+		return StreamKindSubtitle
+	case avMediaTypeData:
+		return StreamKindData
+	case avMediaTypeAttachment:
+		return StreamKindAttachment
+	default:
+		return StreamKindUnknown
+	}
+}
+
+// Information about the container of the media. See [Player.ContainerInfo]().
+type ContainerInfo struct {
+	// Short name of the container format, as reported by ffmpeg. Some
+	// demuxers handle multiple related formats, in which case this is a
+	// comma-separated list (e.g. "mov,mp4,m4a,3gp,3g2,mj2", "matroska,webm").
+	FormatName string
+
+	// Descriptive name of the container format (e.g. "QuickTime / MOV").
+	FormatLongName string
+
+	// Duration declared by the container, which may be 0 if unknown (e.g.
+	// for live streams). See also [Player.DurationAccurate]().
+	Duration time.Duration
+}
+
+// Information about a stream of the media. See [Player.Streams]().
+type StreamInfo struct {
+	// Index of the stream within the container.
+	Index int
+
+	Kind StreamKind
+
+	// Short and descriptive names of the codec (e.g. "h264" and
+	// "H.264 / AVC / MPEG-4 AVC / MPEG-4 part 10"). Can be empty for
+	// streams that ffmpeg can't decode.
+	Codec         string
+	CodecLongName string
+
+	// Bit rate of the stream in bits per second, or 0 if unknown.
+	BitRate int64
+
+	// Size of the encoded frames, only set for video streams.
+	Width, Height int
+
+	// Amount of channels and sample rate of the encoded audio, only
+	// set for audio streams.
+	ChannelCount int
+	SampleRate   int
+}
+
+// Collects the container information of the given media.
+func containerInfoOf(container *reisen.Media) ContainerInfo {
+	info := ContainerInfo{
+		FormatName:     container.FormatName(),
+		FormatLongName: container.FormatLongName(),
+	}
+	duration, err := container.Duration()
+	if err == nil {
+		info.Duration = max(duration, 0)
+	}
+	return info
+}
+
+// Collects the information of all the streams of the given media.
+func streamInfos(container *reisen.Media) []StreamInfo {
+	streams := container.Streams()
+	infos := make([]StreamInfo, 0, len(streams))
+	for _, stream := range streams {
+		info := StreamInfo{
+			Index:         stream.Index(),
+			Kind:          streamKindOf(stream.Type()),
+			Codec:         stream.CodecName(),
+			CodecLongName: stream.CodecLongName(),
+			BitRate:       max(stream.BitRate(), 0),
+		}
+		switch stream := stream.(type) {
+		case *reisen.VideoStream:
+			info.Width, info.Height = stream.Width(), stream.Height()
+		case *reisen.AudioStream:
+			info.ChannelCount, info.SampleRate = stream.ChannelCount(), stream.SampleRate()
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// Returns information about the container of the media, like the format
+// name. Players created from custom controllers return a zero value.
+func (p *Player) ContainerInfo() ContainerInfo {
+	return p.containerInfo
+}
+
+// Returns information about all the streams of the media, including
+// subtitle and data streams that the player ignores, in container order.
+// Together with [Player.ContainerInfo](), this is useful to build media
+// inspection panels. Players created from custom controllers return nil.
+func (p *Player) Streams() []StreamInfo {
+	return slices.Clone(p.streams)
+}
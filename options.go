@@ -0,0 +1,335 @@
+package avebi
+
+import "time"
+
+// Optional configuration for [NewPlayerWithOptions](). The zero value
+// matches the default configuration used by [NewPlayer]().
+type PlayerOptions struct {
+	// Ignores any audio streams, like [NewPlayerWithoutAudio]() does.
+	IgnoreAudio bool
+
+	// Maximum amount of upcoming video frames decoded ahead of time and
+	// kept in memory. Forward seeks that land within the prefetched window
+	// reuse these frames instead of decoding again from the previous
+	// keyframe, which makes scrubbing considerably smoother. Memory usage
+	// grows linearly with the depth (one full RGBA frame per unit).
+	//
+	// Zero disables prefetching. Only videos played without audio support
+	// prefetching at the moment; the option is ignored otherwise.
+	PrefetchDepth int
+
+	// Decodes video frames on a background goroutine instead of on the
+	// goroutine calling [Player.CurrentFrame](), so slow storage (network
+	// mounts, SD cards) doesn't cause hitches in the update loop: if the
+	// next frame is not decoded in time, the current one is kept instead
+	// of blocking. Seeks, looping and other operations that rewind the
+	// decoder still wait for the ongoing read to complete.
+	//
+	// Videos with audio are always decoded on the ebitengine audio
+	// goroutine. With this option, that goroutine doesn't hold the player
+	// state while reading packets and decoding, so [Player.CurrentFrame](),
+	// [Player.Position]() and [Player.State]() don't wait for it either.
+	// Near the end of the video, detecting the end may then be delayed
+	// until the next call that finds the decoder idle.
+	BackgroundDecoding bool
+
+	// Size of the ebitengine audio player buffer. Smaller buffers reduce
+	// latency (e.g. audio responds faster after seeking), but values that
+	// are too small will cause audio glitches. As a reference, 40ms should
+	// be ok on desktops and 70ms on wasm/web. Zero uses the default (200ms).
+	// See also [Player.SetAudioBufferSize]().
+	AudioBufferSize time.Duration
+
+	// Maximum amount of video frames kept in memory while decoding audio
+	// ahead of the video. When audio is decoded far ahead of the video
+	// consumption, the oldest of these frames are discarded once the limit
+	// is exceeded, and included in [Player.DroppedFrameCount](). Zero uses
+	// the default (120 frames), negative values disable the limit. Only
+	// relevant for videos with audio.
+	MaxLeftoverVideoFrames int
+
+	// Maximum amount of packets demuxed on each audio read from ebitengine.
+	// Audio is decoded from the audio thread while holding the player lock,
+	// and when the audio and video packets are unevenly interleaved, reaching
+	// the next audio frame may require going through long runs of video
+	// packets. The limit keeps the work and lock time per read bounded, at
+	// the cost of serving partial or empty reads when reached, which can
+	// starve the audio output and cause glitches if it's too low. Zero uses
+	// the default (256 packets), negative values disable the limit. Only
+	// relevant for videos with audio.
+	MaxPacketsPerRead int
+
+	// Treats decoding errors found near the end of the video as a regular
+	// end of video instead of a failure. This allows playing truncated files
+	// (e.g. interrupted downloads or recordings) to their last valid frame.
+	// Errors are only tolerated if the last decoded frame was within the
+	// final 5% of the video duration (or the last 2 seconds, whichever is
+	// longer), so genuine corruption earlier in the file is still reported.
+	// Tolerated errors are logged as warnings.
+	TolerateTruncatedEnd bool
+
+	// Scale factor in (0, 1] applied to the video resolution when converting
+	// decoded frames to RGBA. For example, 0.5 produces frames at half the
+	// width and height. Smaller frames are cheaper to convert, process and
+	// upload to the GPU, which matters when playing many small videos at once
+	// (e.g. thumbnail grids). The codec itself still decodes at full resolution.
+	// [Player.Resolution]() reports the scaled size. Zero or values outside
+	// the range keep the original resolution.
+	DecodeScale float64
+
+	// Maximum width and height of the decoded frames. Videos exceeding them
+	// are scaled down to fit when converting decoded frames to RGBA, keeping
+	// the aspect ratio, like with DecodeScale, which protects memory budgets
+	// against unexpectedly large files. If DecodeScale is also set, the caps
+	// apply to the scaled resolution. [Player.Resolution]() reports the
+	// reduced size. Zero means no limit, which is the default.
+	MaxWidth  int
+	MaxHeight int
+
+	// Creates the ebitengine audio context automatically if the video has
+	// audio and no context exists yet, using the sample rate of the video
+	// audio. This makes [CreateAudioContextForMedia]() unnecessary. If a
+	// context already exists, it's never replaced, and if its sample rate
+	// doesn't match the video audio, the audio is resampled to the context
+	// sample rate. This is common on web builds, where browsers frequently
+	// impose 48000Hz. Resampling uses linear interpolation, which is cheap
+	// but slightly degrades the audio quality, so matching rates are still
+	// preferable when possible.
+	AutoCreateAudioContext bool
+
+	// Allows playing media without video streams, like mp3 or ogg files,
+	// instead of returning [ErrNoVideo]. Audio-only players work like any
+	// other player, but [Player.CurrentFrame]() always returns a nil image
+	// and [Player.Resolution]() returns (0, 0).
+	AllowAudioOnly bool
+
+	// Index of the audio track to play, for media with multiple audio
+	// tracks (e.g. different languages). See [Player.AudioTracks](). Zero
+	// plays the first track, which is the default. If the track doesn't
+	// exist, an error is returned when creating the player.
+	AudioTrack int
+
+	// Makes [Player.CurrentFrame]() show the first frame of the video while
+	// the player is [Stopped] at the start (e.g. right after creating it or
+	// after [Player.Stop]()), instead of the placeholder color. Unlike with
+	// [Player.Prime](), the player remains [Stopped]. The frame is decoded
+	// with a separate decoder, so this is only available for players created
+	// from a file or URL, and has no effect on live streams.
+	ShowFirstFrameWhenStopped bool
+
+	// Volume of the player on creation, in [0, 1]. Values outside the range
+	// are clamped, and a warning is logged. Nil keeps the default volume of
+	// 1, while zero creates the player silent, but not muted, unlike
+	// InitialMuted. See [Player.SetVolume]().
+	InitialVolume *float64
+
+	// Creates the player already muted, so no audio is ever heard until it's
+	// unmuted with [Player.SetMuted](), which is common for autoplaying
+	// videos. Muting right after creating the player also works, but this
+	// makes the intent explicit and avoids any ordering concerns.
+	InitialMuted bool
+
+	// Duration of recently decoded audio and video kept by players with audio
+	// so [Player.Seek]() can serve small seeks (e.g. scrubbing back a couple
+	// of seconds) by replaying the kept data instead of rewinding and decoding
+	// again, which avoids most of the audible gap. Seeks outside the kept
+	// window still rewind and decode as usual. Zero disables it, which is the
+	// default, as video frames are kept uncompressed: each second of kept
+	// video uses frame rate * width * height * 4 bytes (around 250MB for
+	// 1080p30), though the amount of frames is also limited by
+	// MaxLeftoverVideoFrames. Videos without audio seek within their
+	// prefetched frames instead, see PrefetchDepth.
+	SeekBufferDuration time.Duration
+
+	// Identifier included at the start of the log messages of the player,
+	// like its warnings, see [Player.SetLogPrefix](). Empty by default.
+	LogPrefix string
+}
+
+// Determines what happens to decoded live stream frames when the internal
+// frame queue is full, which happens when frames are decoded faster than
+// they can be presented (e.g. after network bursts).
+type DropPolicy uint8
+
+const (
+	// Decoding waits until there's space in the queue. No frames are lost,
+	// but the presented frames can fall behind the live source. This is
+	// the default.
+	DropPolicyBlock DropPolicy = iota
+
+	// The oldest queued frame is discarded to make space for the new one.
+	// This favors freshness over completeness.
+	DropPolicyDropOldest
+
+	// The newly decoded frame is discarded.
+	DropPolicyDropNewest
+)
+
+// Returns a string representation of the drop policy
+// ("Block", "DropOldest", "DropNewest", "<invalid>").
+func (p DropPolicy) String() string {
+	switch p {
+	case DropPolicyBlock:
+		return "Block"
+	case DropPolicyDropOldest:
+		return "DropOldest"
+	case DropPolicyDropNewest:
+		return "DropNewest"
+	default:
+		return "<invalid>"
+	}
+}
+
+// Optional configuration for [NewStreamPlayerWithOptions](). The zero value
+// matches the default configuration used by [NewStreamPlayer]().
+type StreamOptions struct {
+	// Ignores any audio streams, like [NewStreamPlayerWithoutAudio]() does.
+	IgnoreAudio bool
+
+	// Policy to apply when the decoded frame queue is full. Dropped frames
+	// are included in [Player.DroppedFrameCount]().
+	DropPolicy DropPolicy
+
+	// Minimum amount of decoded frames buffered before presenting the first
+	// frame after [Player.Play](), like a pre-roll. The buffered frames absorb
+	// the initial network jitter, so playback starts smoother, at the cost of
+	// a higher latency. Zero presents the first frame immediately, which is
+	// the default. Values above the frame queue size (64) are capped.
+	PreRollFrames int
+
+	// Like PreRollFrames, but measured as the time span between the first and
+	// last buffered frames. If both are set, both must be reached. Zero
+	// disables it, which is the default.
+	PreRollDuration time.Duration
+
+	// Maximum time to wait for the stream to be opened when creating the
+	// player, which includes connecting to the server and probing the stream
+	// headers. If exceeded, [ErrConnectTimeout] is returned, so unreachable
+	// cameras can be retried instead of freezing the application. Zero waits
+	// indefinitely, which is the default.
+	//
+	// reisen doesn't expose the ffmpeg network options (rw_timeout, stimeout),
+	// so the timeout is enforced on our side: the connection attempt keeps
+	// running in the background until ffmpeg gives up, and its resources
+	// are released then. While such an attempt is pending, opening the same
+	// URL again fails immediately with [ErrConnectTimeout], so retries don't
+	// accumulate background attempts.
+	ConnectTimeout time.Duration
+
+	// Lower transport protocol for RTSP streams: "tcp" (interleaved in the
+	// RTSP connection), "udp", "udp_multicast" or "http" (tunneled). Forcing
+	// "tcp" is usually much more reliable over lossy links, where UDP packets
+	// get lost. Empty uses the ffmpeg default, which tries UDP first and
+	// falls back to TCP. Setting a transport for a non-RTSP URL or using an
+	// unsupported value makes the player creation fail.
+	//
+	// reisen doesn't expose the ffmpeg rtsp_transport option, so the
+	// transport is requested through the equivalent RTSP URL options
+	// (e.g. "rtsp://camera/stream?tcp"), which ffmpeg strips before
+	// connecting. It's applied when the stream is opened.
+	RTSPTransport string
+}
+
+// Determines how videos without audio catch up with the playback position
+// after a large gap between frame requests, e.g. after the update loop
+// stalls due to a long GC pause or loading operation.
+type CatchUpStrategy uint8
+
+const (
+	// All the frames between the last presented frame and the target
+	// position are decoded. This is the default.
+	CatchUpDecodeAll CatchUpStrategy = iota
+
+	// If the gap exceeds a threshold, the decoder seeks to the nearest
+	// keyframe before the target position instead, so only the frames
+	// from that keyframe need to be decoded. This bounds the catch-up
+	// cost, at the price of an extra seek.
+	CatchUpSeekOnLargeGap
+)
+
+// Returns a string representation of the catch-up strategy
+// ("DecodeAll", "SeekOnLargeGap", "<invalid>").
+func (s CatchUpStrategy) String() string {
+	switch s {
+	case CatchUpDecodeAll:
+		return "DecodeAll"
+	case CatchUpSeekOnLargeGap:
+		return "SeekOnLargeGap"
+	default:
+		return "<invalid>"
+	}
+}
+
+// Determines what happens when a video without looping reaches its end.
+type EndBehavior uint8
+
+const (
+	// The video is stopped and the decoder closed, leaving the player
+	// [Stopped] at the end of the video. This is the default.
+	EndStopAndClose EndBehavior = iota
+
+	// The video is paused at the end, leaving the player [Paused] with
+	// the last frame visible and the decoder still open, so the video
+	// can be seeked backwards. Playing again restarts from the beginning.
+	EndPauseAtEnd
+)
+
+// Returns a string representation of the end behavior
+// ("StopAndClose", "PauseAtEnd", "<invalid>").
+func (b EndBehavior) String() string {
+	switch b {
+	case EndStopAndClose:
+		return "StopAndClose"
+	case EndPauseAtEnd:
+		return "PauseAtEnd"
+	default:
+		return "<invalid>"
+	}
+}
+
+// Advanced configuration for [Player.SetChromaKeyWithOptions]().
+type ChromaKeyOptions struct {
+	// Maximum differences in [0, 1] between each pixel channel and the key
+	// color for the pixel to become transparent. Unlike the single tolerance
+	// of [Player.SetChromaKey](), each channel is checked independently,
+	// which makes it possible to accept wider variations in brightness on
+	// unevenly lit screens while staying strict on the other channels.
+	RedTolerance   float64
+	GreenTolerance float64
+	BlueTolerance  float64
+
+	// Amount in [0, 1] of spill suppression applied to the pixels that are
+	// kept. Spill is the key color reflected on the subject, typically
+	// visible as a green fringe around the edges. The dominant channel of
+	// the key color is limited towards the average of the other two
+	// channels, which desaturates the fringe. Notice that this also affects
+	// subject colors similar to the key color. Zero disables it.
+	SpillSuppression float64
+}
+
+// Determines which clock [Player.Position]() reports for videos with audio.
+type PositionSource uint8
+
+const (
+	// The position is derived from the audio playback, which is also the
+	// clock that video frames are synchronized to. This is the default.
+	PositionAudioClock PositionSource = iota
+
+	// The position is the presentation offset of the video frame currently
+	// being shown, which matches what's on screen even for files where the
+	// audio timestamps lead or lag the video, but advances in frame steps.
+	PositionVideoFrame
+)
+
+// Returns a string representation of the position source
+// ("AudioClock", "VideoFrame", "<invalid>").
+func (s PositionSource) String() string {
+	switch s {
+	case PositionAudioClock:
+		return "AudioClock"
+	case PositionVideoFrame:
+		return "VideoFrame"
+	default:
+		return "<invalid>"
+	}
+}
@@ -1,5 +1,11 @@
 package avebi
 
+import (
+	"context"
+	"sync"
+	"time"
+)
+
 // Video playback state can be [Stopped], [Playing] or [Paused].
 type PlaybackState uint8
 
@@ -24,3 +30,102 @@ const (
 	Paused
 	invalidPlaybackState
 )
+
+// Minimum wait between state checks while expecting a natural end, as
+// the actual end can be detected slightly after the predicted one.
+const minStateWaitPeriod = 10 * time.Millisecond
+
+// Broadcasts playback state changes to any number of waiters. Controllers
+// notify it each time their state is set.
+type stateSignal struct {
+	mutex sync.Mutex
+	ch    chan struct{} // closed on the next notify(), nil if no waiters
+}
+
+// Returns a channel that is closed on the next state change.
+func (s *stateSignal) changed() <-chan struct{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.ch == nil {
+		s.ch = make(chan struct{})
+	}
+	return s.ch
+}
+
+// Wakes up all the current waiters. Safe to call while holding a
+// controller mutex.
+func (s *stateSignal) notify() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.ch != nil {
+		close(s.ch)
+		s.ch = nil
+	}
+}
+
+// Implemented by the package controllers to notify state changes.
+// Custom [VideoController] implementations don't need to implement it.
+type stateChangeNotifier interface {
+	stateChanged() <-chan struct{}
+}
+
+// Blocks until the player reaches the given state or the context is
+// done, in which case the context error is returned. This is mainly
+// useful for tests and scripted sequences, e.g. waiting for the end of
+// the video with target [Stopped] (or [Paused] with [EndPauseAtEnd]).
+//
+// The wait is woken up by state changes, as well as at the predicted end
+// of the video while playing, since some state changes are only detected
+// when the player is queried. This method can be called from a goroutine
+// other than the one updating the player, but the player must not be
+// closed while waiting.
+func (p *Player) WaitForState(ctx context.Context, target PlaybackState) error {
+	notifier, _ := p.controller.(stateChangeNotifier)
+	for {
+		// subscribe before checking, so changes in between aren't missed
+		var changed <-chan struct{}
+		if notifier != nil {
+			changed = notifier.stateChanged()
+		}
+
+		state, err := p.State()
+		if err != nil {
+			return err
+		}
+		if state == target {
+			return nil
+		}
+
+		// some changes are only detected on queries, so check again at the
+		// predicted end while playing, or periodically without notifications
+		wait := time.Duration(-1)
+		if state == Playing && p.controller.Duration() > 0 {
+			remaining, err := p.Remaining()
+			if err != nil {
+				return err
+			}
+			wait = max(remaining, minStateWaitPeriod)
+		} else if notifier == nil {
+			wait = minStateWaitPeriod
+		}
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if wait >= 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-changed:
+		case <-timeout:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
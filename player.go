@@ -2,8 +2,14 @@ package avebi
 
 import (
 	"errors"
+	"fmt"
 	"image/color"
+	"io"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/erparts/reisen"
@@ -19,15 +25,36 @@ import (
 //   can make it only work while the video is paused, and it doesn't affect anything else,
 //   it uses the same underlying "current frame" logic in a pretty clean way
 
+// Default gap threshold for [Player.SetCatchUpStrategy]().
+const defaultCatchUpThreshold = time.Second
+
 // A collection of initialization errors defined by this package for [NewPlayer]().
 // Other format-specific errors are also possible.
 var (
 	ErrNoVideo         = errors.New("file doesn't include any video stream")
 	ErrNilAudioContext = errors.New("file has audio stream but audio.Context is not initialized")
-	ErrBadSampleRate   = errors.New("file audio stream and audio context sample rates don't match")
+	ErrBadSampleRate   = errors.New("file audio stream has an invalid sample rate")
 	ErrTooManyChannels = errors.New("file audio streams with more than 2 channels are not supported")
+
+	// Returned by the [Player] methods that need to open the source again
+	// on their own, like [Player.Clone](), for players created from existing
+	// media or custom controllers. Most of them also return it for live streams.
+	ErrNoSource = errors.New("only available for players created from a file or URL")
 )
 
+// Returned by live stream constructors when the stream can't be opened
+// within [StreamOptions].ConnectTimeout.
+var ErrConnectTimeout = errors.New("timed out while opening the stream")
+
+// Returned when decoded frame data doesn't match the expected size for
+// the video resolution and [PixelFormat].
+var ErrBadFrameData = errors.New("decoded frame data doesn't match the video resolution")
+
+// Returned by [Player] methods that can fail once [Player.Close]() has been
+// called, including Close() itself. Methods that don't return errors remain
+// safe to call, but they have no meaningful effect.
+var ErrPlayerClosed = errors.New("player is closed")
+
 // A [Player] represents a video player, typically also including audio.
 //
 // The player is a simple abstraction layer or wrapper around the lower level
@@ -44,79 +71,450 @@ var (
 //
 // [erparts/reisen]: https://github.com/erparts/reisen
 type Player struct {
-	controller        videoController
+	controller        VideoController
 	currentFrame      *ebiten.Image
 	currentPresOffset time.Duration // presentation offset of the current frame
 	frameDuration     time.Duration // TODO: cleanup, remove most likely
 	onBlackFrame      bool
 	reachedEnd        bool
+	keepStoppedFrame  bool // see StopKeepFrame()
+	closed            bool
+	targetFPS         int  // 0 if frames are not decimated
+	snapToFrame       bool // see SetPositionSnapToFrame()
+	snapToPresented   bool // see SetPositionSnapToPresentedFrame()
+	usesNetwork       bool // whether reisen network support must be deinitialized on Close()
+	options           PlayerOptions
+	errorHandler      func(error)
+	placeholderColor  color.Color // shown when there's no frame, black if nil
+	endBehavior       EndBehavior
+	logger            *playerLogger // shared with the controller, nil for custom controllers
+	source            string        // filename or URL, empty for live streams or unknown sources
+
+	// frames decoded on demand, see CachedFrameAt()
+	peeker     *framePeeker
+	frameCache frameCache
+
+	// audio tracks of the media, see AudioTracks()
+	audioTracks []AudioTrackInfo
+
+	// container and streams of the media, see ContainerInfo() and Streams()
+	containerInfo ContainerInfo
+	streams       []StreamInfo
+
+	// cached DurationAccurate() result, 0 if unknown
+	accurateDuration time.Duration
+
+	// frame rate override, see SetFrameRateOverride()
+	frameDurationOverride time.Duration // 0 if not overridden
+	detectedFrameDuration time.Duration // only valid while overridden
+	frameRateOverride     frameRate     // zero if not overridden
+
+	// exact nominal frame rate, see FrameToPosition()
+	detectedFrameRate frameRate // zero for audio-only media
+
+	// cached Keyframes() result, nil if unknown
+	keyframes []time.Duration
+
+	// seek coalescing, see Seek()
+	seekMutex        sync.Mutex    // serializes controller seeks
+	seekGeneration   atomic.Uint64 // incremented on each Seek() and SeekAsync() call
+	pendingSeekMutex sync.Mutex
+	pendingSeek      time.Duration // target of the last Seek() not performed yet
+	hasPendingSeek   bool
+
+	// asynchronous seeks, see SeekAsync()
+	asyncSeeksPending int // not applied yet, only accessed from CurrentFrame() and SeekAsync()
+	asyncSeekMutex    sync.Mutex
+	asyncSeekResults  []asyncSeekResult // completed, protected by asyncSeekMutex
+
+	// automatic pausing, see SetPauseOnUnfocused()
+	pauseOnUnfocused bool
+	wasFocused       bool // focus state on the last check
+	pausedByFocus    bool // paused automatically and not touched since
+
+	// decoding hint, see SetVisible()
+	hidden bool
+
+	// seamless looping, see SetSeamlessLoop()
+	seamlessLoop     bool
+	loopSpare        VideoController // paused at the loop start, nil if not prepared
+	loopSparePending bool            // to be prepared on the next update
+
+	// loop callback, see OnLoop()
+	onLoop         func(loopCount int)
+	loopsSeen      int // controller LoopCount() on the last evaluation
+	completedLoops int // since the playback last started from the beginning
+
+	// first frame callback, see OnFirstFrame()
+	onFirstFrame    func()
+	firstFrameShown bool // since the playback last started from the beginning
+
+	// position triggers, see AddPositionTrigger()
+	triggers        []positionTrigger
+	triggerPosition time.Duration // position on the last triggers evaluation
+
+	// throttled position callback, see OnPositionUpdate()
+	onPositionUpdate       func(position time.Duration)
+	positionUpdateInterval time.Duration
+	lastPositionUpdate     time.Time     // zero if never called
+	positionUpdateValue    time.Duration // position on the last call
+
+	// optional frame processing
+	frameProcessor FrameProcessor
+	chromaKey      chromaKey
+	colorAdjust    colorAdjust
+	frameBuffer    []byte // scratch buffer for frame processing
+	framePixels    []byte // data last written to currentFrame, nil if black
+	frameVersion   uint64 // incremented on each frame change
+
+	// frames written to caller images, see CurrentFrameInto()
+	frameTarget       *ebiten.Image // replaces currentFrame in copyFrame() if not nil
+	currentFrameStale bool          // framePixels were only written to a frameTarget
+	lastIntoVersion   uint64        // frameVersion on the last CurrentFrameInto() call
+
+	// premultiplies the processed frame pixels, see SetPremultiplyAlpha()
+	premultiplyAlpha bool
 }
 
 // Like [NewPlayer](), but ignoring audio streams.
 func NewPlayerWithoutAudio(videoFilename string) (*Player, error) {
-	ignoreAudio := true
-	return newPlayer(videoFilename, ignoreAudio, false)
+	return newPlayer(videoFilename, PlayerOptions{IgnoreAudio: true}, nil)
 }
 
 // Creates a new video [Player]. TODO: ideally we would use io.ReadSeeker,
 // but reisen only has support for explicit filenames.
+//
+// Animated GIFs are also supported. Unlike other videos, they loop by
+// default, and each frame is presented for its own delay, as GIFs don't
+// have a constant frame rate.
 func NewPlayer(videoFilename string) (*Player, error) {
-	ignoreAudio := false
-	return newPlayer(videoFilename, ignoreAudio, false)
+	return newPlayer(videoFilename, PlayerOptions{}, nil)
+}
+
+// Like [NewPlayer](), but with additional configuration options.
+func NewPlayerWithOptions(videoFilename string, opts PlayerOptions) (*Player, error) {
+	return newPlayer(videoFilename, opts, nil)
+}
+
+// Like [NewPlayer](), but for media accessed over the network, like
+// progressive download MP4 files over HTTP(S). Unlike [NewStreamPlayer](),
+// the media is treated as a regular file with a known duration.
+//
+// Seeking and looping require the server to support range requests. If
+// it doesn't, the video can still be played from start to end, but seeking
+// operations will fail.
+func NewPlayerFromURL(url string) (*Player, error) {
+	err := reisen.NetworkInitialize()
+	if err != nil {
+		return nil, err
+	}
+
+	player, err := newPlayer(url, PlayerOptions{}, nil)
+	if err != nil {
+		_ = reisen.NetworkDeinitialize()
+		return nil, err
+	}
+	player.usesNetwork = true
+	return player, nil
 }
 
 // Like [NewPlayer](), but for live streams.
-func NewStreamPlayer(videoFilename string) (*Player, error) {
-	return newPlayer(videoFilename, false, true)
+//
+// Audio is not supported for live streams yet, so any audio streams are
+// ignored and a warning is logged if the stream contains them. Use
+// [NewStreamPlayerWithoutAudio]() to explicitly skip audio instead.
+func NewStreamPlayer(url string) (*Player, error) {
+	return newPlayer(url, PlayerOptions{}, &StreamOptions{})
+}
+
+// Like [NewStreamPlayer](), but explicitly ignoring audio streams.
+func NewStreamPlayerWithoutAudio(url string) (*Player, error) {
+	return newPlayer(url, PlayerOptions{}, &StreamOptions{IgnoreAudio: true})
+}
+
+// Like [NewStreamPlayer](), but with additional configuration options.
+func NewStreamPlayerWithOptions(url string, opts StreamOptions) (*Player, error) {
+	return newPlayer(url, PlayerOptions{}, &opts)
+}
+
+// Like [NewPlayerWithOptions](), but using an already created media
+// container instead of opening a file. This allows advanced users to
+// inspect the media on their own before deciding to play it, without
+// having to open it twice.
+//
+// On success, the player takes ownership of the media: it must not be
+// used directly anymore, and [Player.Close]() will close it. If an error
+// is returned, the media remains the caller's responsibility.
+func NewPlayerFromMedia(media *reisen.Media, opts PlayerOptions) (*Player, error) {
+	if media == nil {
+		panic("nil media")
+	}
+	return newPlayerFromMedia(media, "media", opts, nil)
+}
+
+// Creates a [Player] driven by a custom [VideoController], with frames of
+// the given resolution. This is mainly intended for testing code that uses
+// players with fake controllers. Audio specific methods like [Player.SetVolume]()
+// have no effect on players created this way.
+func NewPlayerWithController(controller VideoController, width, height int) *Player {
+	if controller == nil {
+		panic("nil controller")
+	}
+	if width <= 0 || height <= 0 {
+		panic("invalid frame resolution")
+	}
+
+	img := ebiten.NewImage(width, height)
+	img.Fill(color.Black)
+	return &Player{
+		currentFrame:    img,
+		controller:      controller,
+		onBlackFrame:    true,
+		triggerPosition: triggerPositionReset,
+		frameCache:      frameCache{capacity: defaultFrameCacheSize},
+	}
 }
 
-func newPlayer(videoFilename string, ignoreAudio bool, isStream bool) (*Player, error) {
+// A non-nil streamOpts indicates that the media is a live stream.
+func newPlayer(videoFilename string, opts PlayerOptions, streamOpts *StreamOptions) (*Player, error) {
 	// initialize stream
-	container, err := reisen.NewMedia(videoFilename)
+	var container *reisen.Media
+	var err error
+	openURL := videoFilename
+	if streamOpts != nil && streamOpts.RTSPTransport != "" {
+		openURL, err = rtspURLWithTransport(videoFilename, streamOpts.RTSPTransport)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if streamOpts != nil && streamOpts.ConnectTimeout > 0 {
+		container, err = openMediaWithTimeout(openURL, streamOpts.ConnectTimeout)
+	} else {
+		container, err = reisen.NewMedia(openURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	player, err := newPlayerFromMedia(container, filepath.Base(videoFilename), opts, streamOpts)
+	if err != nil {
+		container.Close()
+		return nil, err
+	}
+	if streamOpts == nil {
+		player.source = videoFilename
+	}
+	return player, nil
+}
+
+// Returns the given RTSP URL with the URL option that makes ffmpeg use the
+// given lower transport, see [StreamOptions].RTSPTransport.
+func rtspURLWithTransport(url, transport string) (string, error) {
+	var option string
+	switch transport {
+	case "tcp", "udp", "http":
+		option = transport
+	case "udp_multicast":
+		option = "multicast"
+	default:
+		return "", fmt.Errorf("unsupported RTSP transport '%s' (expected tcp, udp, udp_multicast or http)", transport)
+	}
+	scheme, _, _ := strings.Cut(url, "://")
+	if scheme = strings.ToLower(scheme); scheme != "rtsp" && scheme != "rtsps" {
+		return "", fmt.Errorf("RTSP transport set for a non-RTSP URL '%s'", url)
+	}
+
+	// options follow the last '?', separated by '&'
+	if strings.Contains(url, "?") {
+		return url + "&" + option, nil
+	}
+	return url + "?" + option, nil
+}
+
+// URLs with an open abandoned by openMediaWithTimeout() that is still
+// running in the background. See openMediaWithTimeout().
+var pendingOpens = struct {
+	mutex sync.Mutex
+	urls  map[string]int // amount of pending opens per URL
+}{urls: make(map[string]int)}
+
+// Like reisen.NewMedia(), but giving up after the given timeout. The
+// blocking open can't be interrupted, so on timeout it's left running
+// in the background, and the media is closed once it finishes. Until
+// then, the goroutine and the connection attempt are leaked, which can
+// take as long as ffmpeg's own network timeouts (or forever, if the server
+// accepts the connection but never responds).
+//
+// To prevent retries against an unresponsive server from piling up leaked
+// opens, new attempts for a URL fail immediately with [ErrConnectTimeout]
+// while an abandoned open for the same URL is still pending.
+func openMediaWithTimeout(url string, timeout time.Duration) (*reisen.Media, error) {
+	pendingOpens.mutex.Lock()
+	pending := pendingOpens.urls[url] > 0
+	pendingOpens.mutex.Unlock()
+	if pending {
+		return nil, fmt.Errorf("%w (%s, a previous attempt is still pending)", ErrConnectTimeout, url)
+	}
+
+	type openResult struct {
+		media *reisen.Media
+		err   error
+	}
+	results := make(chan openResult, 1) // buffered, so the opener never blocks
+	go func() {
+		media, err := reisen.NewMedia(url)
+		results <- openResult{media, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case result := <-results:
+		return result.media, result.err
+	case <-timer.C:
+		pendingOpens.mutex.Lock()
+		pendingOpens.urls[url] += 1
+		pendingOpens.mutex.Unlock()
+		go func() {
+			result := <-results
+			if result.err == nil {
+				result.media.Close()
+			}
+			pendingOpens.mutex.Lock()
+			if pendingOpens.urls[url] -= 1; pendingOpens.urls[url] == 0 {
+				delete(pendingOpens.urls, url)
+			}
+			pendingOpens.mutex.Unlock()
+		}()
+		return nil, fmt.Errorf("%w (%s after %s)", ErrConnectTimeout, url, timeout)
+	}
+}
+
+// The name is only used to identify the media on log messages. A non-nil
+// streamOpts indicates that the media is a live stream.
+func newPlayerFromMedia(container *reisen.Media, name string, opts PlayerOptions, streamOpts *StreamOptions) (*Player, error) {
+	logger := newPlayerLogger(opts.LogPrefix)
+	controller, videoStream, err := newController(container, name, opts, streamOpts, logger)
 	if err != nil {
 		return nil, err
 	}
 
+	// audio-only media doesn't have frames nor frame images
+	if videoStream == nil {
+		return &Player{
+			controller:      controller,
+			logger:          logger,
+			onBlackFrame:    true,
+			triggerPosition: triggerPositionReset,
+			options:         opts,
+			frameCache:      frameCache{capacity: defaultFrameCacheSize},
+			audioTracks:     playerAudioTracks(container, controller, opts, streamOpts),
+			containerInfo:   containerInfoOf(container),
+			streams:         streamInfos(container),
+		}, nil
+	}
+
+	// compute frame duration for later use
+	frameDuration := nominalFrameDuration(videoStream)
+
+	// create video player
+	width, height := videoStream.Width(), videoStream.Height()
+	if streamOpts == nil {
+		width, height = decodeResolution(videoStream, opts)
+	}
+	img := ebiten.NewImage(width, height)
+	img.Fill(color.Black)
+	return &Player{
+		currentFrame:      img,
+		controller:        controller,
+		logger:            logger,
+		frameDuration:     frameDuration,
+		detectedFrameRate: nominalFrameRate(videoStream),
+		onBlackFrame:      true,
+		triggerPosition:   triggerPositionReset,
+		options:           opts,
+		frameCache:        frameCache{capacity: defaultFrameCacheSize},
+		audioTracks:       playerAudioTracks(container, controller, opts, streamOpts),
+		containerInfo:     containerInfoOf(container),
+		streams:           streamInfos(container),
+	}, nil
+}
+
+// Creates the appropriate controller for the given media, also returning
+// the video stream being played, which is nil for audio-only media. The
+// media is not closed on error. The logger is shared with the controller.
+func newController(container *reisen.Media, name string, opts PlayerOptions, streamOpts *StreamOptions, logger *playerLogger) (VideoController, *reisen.VideoStream, error) {
+	var err error
+
 	// make sure there's video stream and headers
 	videoStreams := container.VideoStreams()
 	audioStreams := container.AudioStreams()
 	if len(videoStreams) == 0 {
-		return nil, ErrNoVideo
+		if opts.AllowAudioOnly && len(audioStreams) > 0 && streamOpts == nil {
+			audioStream, err := selectAudioStream(audioStreams, opts)
+			if err != nil {
+				return nil, nil, err
+			}
+			if err := ensureAudioContext(opts, audioStream); err != nil {
+				return nil, nil, err
+			}
+			controller, err := newVideoWithAudioController(container, nil, audioStream, opts, logger)
+			return controller, nil, err
+		}
+		return nil, nil, ErrNoVideo
 	}
 	if len(videoStreams) > 1 {
-		pkgLogger.Printf("WARNING: '%s' has multiple video streams; defaulting to the first", filepath.Base(videoFilename))
+		logger.Printf("WARNING: '%s' has multiple video streams; defaulting to the first", name)
 	}
 	videoStream := videoStreams[0]
-
-	// compute frame duration for later use
-	frNum, frDenom := videoStream.FrameRate()
-	frameDuration := (time.Second * time.Duration(frDenom)) / time.Duration(frNum)
+	if !hasValidFrameRate(videoStream) {
+		frNum, frDenom := videoStream.FrameRate()
+		logger.Printf("WARNING: '%s' reports an invalid frame rate (%d/%d); assuming %dfps", name, frNum, frDenom, fallbackFrameRate)
+	}
 
 	// check if there's audio streams
-	var controller videoController
+	var controller VideoController
 
 	switch {
-	case isStream:
-		controller, err = newStreamVideoController(container, videoStream)
-	case len(audioStreams) > 0 && !ignoreAudio:
-		controller, err = newVideoWithAudioController(container, videoStream, audioStreams[0])
+	case streamOpts != nil:
+		if len(audioStreams) > 0 && !streamOpts.IgnoreAudio {
+			logger.Printf("WARNING: '%s' has audio streams, but audio is not supported on live streams; ignoring audio", name)
+		}
+		controller, err = newStreamVideoController(container, videoStream, *streamOpts)
+	case len(audioStreams) > 0 && !opts.IgnoreAudio:
+		var audioStream *reisen.AudioStream
+		audioStream, err = selectAudioStream(audioStreams, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		err = ensureAudioContext(opts, audioStream)
+		if err != nil {
+			return nil, nil, err
+		}
+		controller, err = newVideoWithAudioController(container, videoStream, audioStream, opts, logger)
 	default:
-		controller, err = newVideoOnlyController(container, videoStream)
+		controller, err = newVideoOnlyController(container, videoStream, opts, logger)
 	}
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	return controller, videoStream, nil
+}
 
-	// create video player
-	img := ebiten.NewImage(videoStream.Width(), videoStream.Height())
-	img.Fill(color.Black)
-	return &Player{
-		currentFrame:  img,
-		controller:    controller,
-		frameDuration: frameDuration,
-		onBlackFrame:  true,
-	}, nil
+// Creates the audio context for the given stream if required by the
+// options and no context exists yet. See PlayerOptions.AutoCreateAudioContext.
+// An existing context is kept even if its sample rate doesn't match, as
+// the audio is resampled in that case, see newVideoWithAudioController().
+func ensureAudioContext(opts PlayerOptions, audioStream *reisen.AudioStream) error {
+	if !opts.AutoCreateAudioContext {
+		return nil
+	}
+	err := createAudioContext(audioStream.SampleRate())
+	if errors.Is(err, ErrNonNilAudioContext) {
+		return nil
+	}
+	return err
 }
 
 // --- frames and resolution ---
@@ -129,19 +527,92 @@ func newPlayer(videoFilename string, ignoreAudio bool, isStream bool) (*Player,
 // The returned image is reused, so calling this method again will overwrite
 // its contents. This means you can use the image between calls, but you should
 // not store it for later use expecting the image to remain the same.
+//
+// For audio-only media (see [PlayerOptions].AllowAudioOnly), the returned
+// image is always nil.
+//
+// While the player is [Stopped], the image is filled with the placeholder
+// color (see [Player.SetPlaceholderColor]()), unless the video has reached
+// the end or was stopped with [Player.StopKeepFrame](), which keep the
+// last frame, or [PlayerOptions].ShowFirstFrameWhenStopped is set, which
+// shows the first frame of the video.
+//
+// If the frame hasn't changed since the previous call, the same image is
+// returned without copying any data, so showing the same video in multiple
+// viewports only requires drawing the returned image multiple times (see
+// [Draw]()). Showing a video at two different positions at once, instead,
+// requires a separate [Player] for each position, as each player has a
+// single decoder.
 func (p *Player) CurrentFrame() (*ebiten.Image, error) {
+	frame, err := p.updateFrame()
+	if err != nil || frame == nil {
+		return frame, err
+	}
+	return p.syncedFrame(), nil
+}
+
+// Implements [Player.CurrentFrame](), but frames are written to
+// p.frameTarget instead of p.currentFrame if set. Returns p.currentFrame,
+// which can be stale in that case, see syncedFrame().
+func (p *Player) updateFrame() (*ebiten.Image, error) {
+	if p.closed {
+		return nil, ErrPlayerClosed
+	}
+	if p.asyncSeeksPending > 0 {
+		p.applyAsyncSeeks()
+		if p.asyncSeeksPending > 0 {
+			return p.currentFrame, nil // the controller is busy seeking
+		}
+	}
+	if err := p.applyPendingSeek(); err != nil {
+		return nil, err
+	}
+	if err := p.updateFocusPause(); err != nil {
+		return nil, err
+	}
+	if err := p.prepareDeferredLoopSpare(); err != nil {
+		return nil, err
+	}
+	if p.hidden {
+		return p.currentHiddenFrame()
+	}
 	frame, reachedEnd, err := p.controller.CurrentVideoFrame()
 	if err != nil {
 		return nil, err
 	}
+	if reachedEnd && p.seamlessLoop {
+		frame, reachedEnd, err = p.swapLoopSpare()
+		if err != nil {
+			return nil, err
+		}
+	}
 	if reachedEnd {
 		p.reachedEnd = true
 	}
+	if err := p.updatePositionTriggers(); err != nil {
+		return nil, err
+	}
+	if err := p.updatePositionCallback(); err != nil {
+		return nil, err
+	}
+	p.updateLoops()
+	if p.currentFrame == nil { // audio-only media
+		return nil, nil
+	}
 	if frame == nil {
 		// we either reached end or had been stopped already
-		if !p.reachedEnd {
-			p.copyFrame(frame)
+		if p.reachedEnd || p.keepStoppedFrame {
+			return p.currentFrame, nil
+		}
+		if p.options.ShowFirstFrameWhenStopped && p.source != "" && p.peekState() == Stopped {
+			if p.onBlackFrame {
+				if err := p.showFirstFrame(); err != nil {
+					return nil, err
+				}
+			}
+			return p.currentFrame, nil
 		}
+		p.clearFrame()
 		return p.currentFrame, nil
 	}
 
@@ -153,13 +624,151 @@ func (p *Player) CurrentFrame() (*ebiten.Image, error) {
 	if presOffset != p.currentPresOffset || p.currentFrame == nil || p.onBlackFrame { // *
 		// * the p.onBlackFrame condition is for safety to disambiguate the zero
 		//   value of currentPresOffset with frames starting at exactly 0
+		if p.isDecimatedFrame(presOffset) {
+			return p.currentFrame, nil
+		}
 		p.currentPresOffset = presOffset
-		p.copyFrame(frame)
+		if err := p.copyFrame(frame); err != nil {
+			return nil, err
+		}
+		if !p.firstFrameShown {
+			p.firstFrameShown = true
+			if p.onFirstFrame != nil {
+				p.onFirstFrame()
+			}
+		}
 		return p.currentFrame, nil
 	}
 	return p.currentFrame, nil
 }
 
+// Sets how the video catches up with the playback position after a large
+// gap between [Player.CurrentFrame]() calls, like after a stalled update
+// loop. With [CatchUpSeekOnLargeGap], gaps longer than the given threshold
+// are handled by seeking to the nearest keyframe before the target position
+// instead of decoding every intermediate frame. Zero or negative thresholds
+// use a default of one second. The default strategy is [CatchUpDecodeAll].
+//
+// Only videos without audio are affected, as frames for videos with audio
+// are decoded along with the audio stream, and live streams don't catch up.
+func (p *Player) SetCatchUpStrategy(strategy CatchUpStrategy, threshold time.Duration) {
+	controller, isVideoOnly := p.controller.(*videoOnlyController)
+	if !isVideoOnly {
+		return
+	}
+	if threshold <= 0 {
+		threshold = defaultCatchUpThreshold
+	}
+	controller.SetCatchUpStrategy(strategy, threshold)
+}
+
+// Sets the color of the image returned by [Player.CurrentFrame]() while
+// there's no video frame to show, like before the playback starts or after
+// stopping. The default is black, which can be jarring on light interfaces.
+// Transparent colors are also allowed, e.g. for video overlays. Passing nil
+// restores the default.
+func (p *Player) SetPlaceholderColor(clr color.Color) {
+	p.placeholderColor = clr
+	if p.onBlackFrame && p.currentFrame != nil {
+		p.currentFrame.Fill(p.getPlaceholderColor())
+		p.frameVersion += 1
+	}
+}
+
+// Returns the color set with [Player.SetPlaceholderColor](), or black
+// if none has been set.
+func (p *Player) getPlaceholderColor() color.Color {
+	if p.placeholderColor == nil {
+		return color.Black
+	}
+	return p.placeholderColor
+}
+
+// Limits the amount of frames presented per second by [Player.CurrentFrame]().
+// Frames that would follow the previous presented frame too closely are
+// skipped and the previous frame is kept instead, which reduces the cost
+// of copying frames to the GPU on low-power devices (e.g. playing a 60fps
+// video at 30fps). The playback clock is not affected. Frames are still
+// decoded, as codecs need them to decode the following frames.
+//
+// Setting 0 disables frame decimation, which is the default.
+func (p *Player) SetTargetFPS(fps int) {
+	p.targetFPS = max(fps, 0)
+}
+
+// Returns the target frame rate set with [Player.SetTargetFPS](), or 0
+// if frames are not being decimated.
+func (p *Player) GetTargetFPS() int {
+	return p.targetFPS
+}
+
+// Like [Player.CurrentFrame](), but returns a copy of the frame pixels
+// instead of writing them to an image. This allows processing frames
+// without a GPU, e.g. for headless rendering. The data is in the
+// [Player.PixelFormat](), row by row and without padding, with any
+// frame processing already applied. The returned slice is owned by the
+// caller and is not modified by later calls.
+//
+// Returns [ErrNoFrame] if no video frame is available, typically because
+// the player is stopped. This method doesn't update the image returned
+// by [Player.CurrentFrame](), so the two can be freely mixed.
+func (p *Player) CurrentFrameData() ([]byte, int, int, error) {
+	if p.closed {
+		return nil, 0, 0, ErrPlayerClosed
+	}
+	width, height := p.Resolution()
+	if err := p.applyPendingSeek(); err != nil {
+		return nil, width, height, err
+	}
+	frame, reachedEnd, err := p.controller.CurrentVideoFrame()
+	if err != nil {
+		return nil, width, height, err
+	}
+	if reachedEnd {
+		p.reachedEnd = true
+	}
+	if frame == nil {
+		return nil, width, height, ErrNoFrame
+	}
+
+	pixels := frame.Data()
+	if err := p.checkFrameData(pixels); err != nil {
+		return nil, width, height, err
+	}
+	pixels = slices.Clone(pixels)
+	p.processPixels(pixels)
+	return pixels, width, height, nil
+}
+
+// Advanced: like [Player.CurrentFrameData](), but returns the underlying
+// reisen frame directly, without any copies, so its Data() and
+// PresentationOffset() can be used to manage GPU uploads manually.
+//
+// This is unsafe in the sense that the frame is owned by the player: it
+// must be treated as read-only, and it must not be retained after the
+// next call to any player method, as decoders can reuse frame memory. Frame
+// processing and chroma keying are not applied. Returns [ErrNoFrame] if no
+// video frame is available, typically because the player is stopped.
+func (p *Player) CurrentRawFrame() (*reisen.VideoFrame, error) {
+	if p.closed {
+		return nil, ErrPlayerClosed
+	}
+	if err := p.applyPendingSeek(); err != nil {
+		return nil, err
+	}
+	frame, reachedEnd, err := p.controller.CurrentVideoFrame()
+	if err != nil {
+		return nil, err
+	}
+	if reachedEnd {
+		p.reachedEnd = true
+	}
+	if frame == nil {
+		return nil, ErrNoFrame
+	}
+	return frame, nil
+}
+
 // Advances the video stream by one frame. This can be used while a video is paused to
 // examine it frame by frame. Going back is not natively supported by the streams and
 // would require a much more complex implementation.
@@ -167,19 +776,196 @@ func (p *Player) NextVideoFrame() (*ebiten.Image, error) {
 	panic("unimplemented")
 }
 
-// Returns the width and height of the video.
+// Moves back to the previous video frame. This can only be used while the video is
+// [Paused], and only if [Player.IsSeekable](). Since streams can't be decoded
+// backwards, this rewinds to the keyframe preceding the current frame and decodes
+// forward until reaching the previous frame, which is considerably slower than
+// moving forward. If the current frame is the first one, it's returned unchanged.
+func (p *Player) PreviousVideoFrame() (*ebiten.Image, error) {
+	if p.closed {
+		return nil, ErrPlayerClosed
+	}
+	if !p.controller.IsSeekable() {
+		return nil, fmt.Errorf("cannot step back, as the video doesn't support seeking")
+	}
+	if err := p.applyPendingSeek(); err != nil {
+		return nil, err
+	}
+	state, err := p.controller.State()
+	if err != nil {
+		return nil, err
+	}
+	if state != Paused {
+		return nil, fmt.Errorf("cannot step back while the video is %s, pause it first", state)
+	}
+	if p.onBlackFrame || p.currentPresOffset <= 0 {
+		return p.syncedFrame(), nil
+	}
+
+	// seek right before the current frame, so the frame containing that
+	// position, which is the previous one, becomes the current frame
+	err = p.Seek(p.currentPresOffset - 1)
+	if err != nil {
+		return nil, err
+	}
+	return p.CurrentFrame()
+}
+
+// A FrameProcessor can modify the pixels of a video frame in place before
+// they are written to the image returned by [Player.CurrentFrame](). Pixels
+// are in RGBA format, 4 bytes per pixel, row by row, without padding.
+//
+// Ebitengine images use premultiplied alpha, so processors that modify the
+// alpha channel must output premultiplied values (each color channel scaled
+// by alpha), unless [Player.SetPremultiplyAlpha]() is enabled, in which case
+// they must output straight (non-premultiplied) alpha instead.
+type FrameProcessor func(pixels []byte, width, height int)
+
+// Sets a function to process each new video frame before it's written
+// to the frame image. This can be used to implement CPU color grading,
+// LUTs, keying and similar effects. The processor only runs when a new
+// frame is actually presented, not when [Player.CurrentFrame]() returns
+// the same frame again. Built-in processing like [Player.SetChromaKey]()
+// is applied before the processor. Passing nil removes the processor.
+func (p *Player) SetFrameProcessor(processor FrameProcessor) {
+	p.frameProcessor = processor
+}
+
+// Enables chroma keying: pixels whose color is close enough to the key
+// color become fully transparent, which allows turning green screen videos
+// into overlays. The tolerance is the maximum normalized RGB distance to
+// the key color, in [0, 1]. Values around 0.2-0.4 tend to work well for
+// evenly lit green screens. Passing a nil key disables chroma keying.
+//
+// Decoded frames are RGBA with an opaque alpha channel, so the key only
+// depends on the RGB values. The change applies from the next new frame.
+func (p *Player) SetChromaKey(key color.Color, tolerance float64) {
+	p.chromaKey = newChromaKey(key, tolerance)
+}
+
+// Enables chroma keying like [Player.SetChromaKey](), but with separate
+// tolerances for each channel and optional spill suppression, which give
+// cleaner cutouts for unevenly lit green screens. For example, a green key
+// with a higher green tolerance accepts both bright and dark areas of the
+// screen. See [ChromaKeyOptions] for details. Passing a nil key disables
+// chroma keying. The change applies from the next new frame.
+func (p *Player) SetChromaKeyWithOptions(key color.Color, opts ChromaKeyOptions) {
+	p.chromaKey = newChromaKeyWithOptions(key, opts)
+}
+
+// Sets brightness, contrast and gamma adjustments to be applied to each new
+// video frame before it's written to the frame image, so the adjustments are
+// computed once per frame instead of on every draw. Brightness is an offset
+// in [-1, 1], where 0 means no change. Contrast scales the distance to mid
+// gray, where 1 means no change. Gamma is applied as value^(1/gamma), so
+// values above 1 brighten mid tones, and 1 means no change.
+//
+// Calling SetColorAdjust(0, 1, 1) disables the adjustments, which is the
+// default, so there's no processing cost when they are not used. The change
+// applies from the next new frame, after [Player.SetChromaKey]() and before
+// [Player.SetFrameProcessor]().
+func (p *Player) SetColorAdjust(brightness, contrast, gamma float64) {
+	p.colorAdjust = newColorAdjust(brightness, contrast, gamma)
+}
+
+// Sets whether processed frame pixels must be converted from straight to
+// premultiplied alpha before being written to the frame image. Ebitengine
+// images expect premultiplied alpha, so this must be enabled when the
+// [FrameProcessor] outputs straight alpha (e.g. semi-transparent pixels from
+// a soft mask), as otherwise those pixels look too bright when drawn.
+//
+// Decoded frames are fully opaque, and the built-in processing already
+// produces valid premultiplied values, so this is only needed for custom
+// processors. The conversion is applied after all the processing, and only
+// when a new frame is presented. The default is false. The change applies
+// from the next new frame.
+func (p *Player) SetPremultiplyAlpha(premultiply bool) {
+	p.premultiplyAlpha = premultiply
+}
+
+// Returns whether processed frame pixels are premultiplied. See
+// [Player.SetPremultiplyAlpha]().
+func (p *Player) GetPremultiplyAlpha() bool {
+	return p.premultiplyAlpha
+}
+
+// Returns the pixel format of the decoded video frames. reisen converts
+// all video streams to RGBA, so this is always [PixelFormatRGBA] at the
+// moment, but frame processors should still check it if they depend on it.
+func (p *Player) PixelFormat() PixelFormat {
+	return PixelFormatRGBA
+}
+
+// Returns the width and height of the video frames. This is the scaled
+// resolution if [PlayerOptions].DecodeScale, MaxWidth or MaxHeight were used.
 func (p *Player) Resolution() (int, int) {
 	// resolution could also be obtained from the video stream itself
+	if p.currentFrame == nil { // audio-only media
+		return 0, 0
+	}
 	bounds := p.currentFrame.Bounds()
 	return bounds.Dx(), bounds.Dy()
 }
 
+// Returns the amount of decoded video frames that were discarded without
+// ever being presented, since the player was created. Frames are dropped
+// when [Player.CurrentFrame]() is not called often enough to keep up with
+// the video frame rate, so a steadily growing count suggests that the
+// update loop is too slow for the video resolution or frame rate.
+func (p *Player) DroppedFrameCount() int {
+	return p.controller.DroppedFrameCount()
+}
+
 // ---- video playback states ----
 
 // Returns the current player's state, which can be [Stopped], [Playing] or
 // [Paused]. Notice that even when playing, video frames need to be retrieved
 // manually through [Player.CurrentFrame]().
-func (p *Player) State() (PlaybackState, error) { return p.controller.State() }
+//
+// The end of the video is detected lazily, so this method can stop (or pause,
+// see [Player.SetEndBehavior]()) the video as a side effect if it has already
+// reached the end. Use [Player.PeekState]() to observe the state without that.
+func (p *Player) State() (PlaybackState, error) {
+	if p.closed {
+		return invalidPlaybackState, ErrPlayerClosed
+	}
+	if err := p.applyPendingSeek(); err != nil {
+		return invalidPlaybackState, err
+	}
+	return p.controller.State()
+}
+
+// Implemented by the package controllers to return the state without the
+// side effects of State(): if the video has reached the end but that hasn't
+// been detected yet, the returned state is still [Playing]. Custom
+// [VideoController] implementations don't need to implement it.
+type statePeeker interface {
+	PeekState() PlaybackState
+}
+
+// Like [Player.State](), but without side effects: the end of the video is
+// never handled by this method, so a video that has reached the end is still
+// reported as [Playing] until it's detected by another call (e.g. the next
+// [Player.CurrentFrame]()). This is useful for code that merely inspects
+// the state, like debug overlays, and must not interfere with the playback.
+// For custom controllers (see [NewPlayerWithController]()), this is the same
+// as [Player.State]().
+func (p *Player) PeekState() (PlaybackState, error) {
+	if p.closed {
+		return invalidPlaybackState, ErrPlayerClosed
+	}
+	if peeker, ok := p.controller.(statePeeker); ok {
+		return peeker.PeekState(), nil
+	}
+	return p.controller.State()
+}
+
+// Like [Player.PeekState](), for internal use on open players. Errors
+// from custom controllers are ignored.
+func (p *Player) peekState() PlaybackState {
+	state, _ := p.PeekState()
+	return state
+}
 
 // HasEnded returns whether the video has ended.
 func (p *Player) HasEnded() bool { return p.reachedEnd }
@@ -191,36 +977,323 @@ func (p *Player) HasEnded() bool { return p.reachedEnd }
 // start or resume. Video frames need to be retrieved manually through
 // [Player.CurrentFrame]() instead.
 func (p *Player) Play() error {
-	if p.reachedEnd {
-		p.copyFrame(nil)
+	if p.closed {
+		return ErrPlayerClosed
+	}
+	if err := p.applyPendingSeek(); err != nil {
+		return err
+	}
+	if p.reachedEnd || p.keepStoppedFrame {
+		p.clearFrame()
 		p.currentPresOffset = 0
 		p.reachedEnd = false
+		p.keepStoppedFrame = false
+		p.triggerPosition = triggerPositionReset
+		p.resetLoops()
+		p.firstFrameShown = false
 	}
 
+	p.pausedByFocus = false
 	return p.controller.Play()
 }
 
-// Pauses the player's playback clock. If the player is already paused, it
-// just stays paused and nothing new happens.
-//
-// If the underlying mpeg contains any audio, the audio will also be paused.
-func (p *Player) Pause() error { return p.controller.Pause() }
+// Implemented by the package controllers that can rewind to position 0 and
+// start playing reusing the open decoder instead of stopping and reopening
+// the streams. If the video is [Stopped], it's equivalent to Play().
+// Custom [VideoController] implementations don't need to implement it.
+type restarter interface {
+	Restart() error
+}
 
-// Stops the player. Using [Player.Play]() again will cause the video to
-// restart from the beginning.
-func (p *Player) Stop() error {
-	p.currentPresOffset = 0
-	p.copyFrame(nil)
-	return p.controller.Stop()
+// Rewinds the video to the start and plays it, like [Player.Stop]()
+// followed by [Player.Play](), but without closing and reopening the
+// decoder, so it's much cheaper. This is useful for quickly replaying
+// short clips, like UI animations triggered repeatedly. The current frame
+// is kept until the first frame is decoded again, without flashing black.
+//
+// Live streams can't be restarted and return an error. Custom controllers
+// (see [NewPlayerWithController]()) are simply stopped and played again.
+func (p *Player) Restart() error {
+	if p.closed {
+		return ErrPlayerClosed
+	}
+	p.reachedEnd = false
+	p.keepStoppedFrame = false
+	p.pausedByFocus = false
+	p.triggerPosition = triggerPositionReset
+	p.resetLoops()
+	p.firstFrameShown = false
+	if restarter, ok := p.controller.(restarter); ok {
+		return restarter.Restart()
+	}
+	err := p.controller.Stop()
+	if err != nil {
+		return err
+	}
+	return p.controller.Play()
 }
 
-// --- timing ---
+// Prime() decodes and shows the first video frame without starting the
+// playback clock nor the audio, leaving the player [Paused] at position 0.
+// This is useful to display the opening frame as a poster image before
+// the user decides to play the video.
+//
+// If the player is not [Stopped], this method does nothing. Live streams
+// can't be primed and will return an error.
+func (p *Player) Prime() error {
+	if p.closed {
+		return ErrPlayerClosed
+	}
+	frame, err := p.controller.Prime()
+	if err != nil || frame == nil {
+		return err
+	}
+
+	presOffset, err := frame.PresentationOffset()
+	if err != nil {
+		return err
+	}
+	p.reachedEnd = false
+	p.keepStoppedFrame = false
+	p.currentPresOffset = presOffset
+	return p.copyFrame(frame)
+}
+
+// Pauses the player's playback clock. If the player is already paused, it
+// just stays paused and nothing new happens.
+//
+// If the underlying mpeg contains any audio, the audio will also be paused.
+func (p *Player) Pause() error {
+	if p.closed {
+		return ErrPlayerClosed
+	}
+	p.pausedByFocus = false
+	return p.controller.Pause()
+}
+
+// Stops the player. Using [Player.Play]() again will cause the video to
+// restart from the beginning.
+func (p *Player) Stop() error {
+	if p.closed {
+		return ErrPlayerClosed
+	}
+	p.discardPendingSeek()
+	p.currentPresOffset = 0
+	p.triggerPosition = triggerPositionReset
+	p.keepStoppedFrame = false
+	p.pausedByFocus = false
+	p.clearFrame()
+	p.resetLoops()
+	p.firstFrameShown = false
+	return p.controller.Stop()
+}
+
+// Stops the player like [Player.Stop](), releasing the decoder and rewinding
+// to the start, but keeps the last presented frame visible instead of
+// clearing it to black. This is useful to free resources while still showing
+// the video as a poster. Unlike [Player.Pause](), using [Player.Play]() again
+// will restart the video from the beginning, clearing the kept frame.
+func (p *Player) StopKeepFrame() error {
+	if p.closed {
+		return ErrPlayerClosed
+	}
+	err := p.controller.Stop()
+	if err != nil {
+		return err
+	}
+	p.triggerPosition = triggerPositionReset
+	p.keepStoppedFrame = !p.onBlackFrame
+	p.pausedByFocus = false
+	p.resetLoops()
+	p.firstFrameShown = false
+	return nil
+}
+
+// Sets whether the player must pause automatically when the window loses
+// focus, and resume when the focus is regained, which is typical for games.
+// The focus (see [ebiten.IsFocused]()) is checked on [Player.CurrentFrame]()
+// calls, so audio-only players must keep calling it too. Pausing uses the
+// regular [Player.Pause]() path, so the audio also halts. Disabled by default.
+//
+// Only focus changes are acted upon, so explicit calls are never overridden:
+// playing the video while unfocused keeps it playing, and the video is only
+// resumed on focus regain if it was paused automatically and no playback
+// method (play, pause, stop...) has been called since.
+func (p *Player) SetPauseOnUnfocused(pause bool) {
+	p.pauseOnUnfocused = pause
+	p.wasFocused = true // so enabling it while unfocused already pauses
+	p.pausedByFocus = false
+}
+
+// Returns whether the player pauses automatically when the window loses
+// focus. See [Player.SetPauseOnUnfocused]().
+func (p *Player) GetPauseOnUnfocused() bool {
+	return p.pauseOnUnfocused
+}
+
+// Pauses or resumes the video on focus changes, if enabled.
+// See SetPauseOnUnfocused().
+func (p *Player) updateFocusPause() error {
+	if !p.pauseOnUnfocused {
+		return nil
+	}
+	focused := ebiten.IsFocused()
+	if focused == p.wasFocused {
+		return nil
+	}
+	p.wasFocused = focused
+
+	if !focused && p.peekState() == Playing {
+		err := p.controller.Pause()
+		p.pausedByFocus = err == nil
+		return err
+	}
+	if focused && p.pausedByFocus {
+		p.pausedByFocus = false
+		if p.peekState() == Paused {
+			return p.controller.Play()
+		}
+	}
+	return nil
+}
+
+// --- timing ---
 
 // Returns the player's current playback position. If the video is
 // [Stopped], the position can only be 0 (start) or [Player.Duration]().
 // (if the video naturally reached the end).
 func (p *Player) Position() (time.Duration, error) {
-	return p.controller.Position()
+	if p.closed {
+		return 0, ErrPlayerClosed
+	}
+	if err := p.applyPendingSeek(); err != nil {
+		return 0, err
+	}
+	position, err := p.controller.Position()
+	if err != nil {
+		return position, err
+	}
+	if p.snapToPresented && !p.onBlackFrame && p.currentFrame != nil && p.peekState() != Stopped {
+		if position < p.controller.Duration() {
+			return p.currentPresOffset, nil
+		}
+	}
+	if !p.snapToFrame || p.frameDuration <= 0 {
+		return position, nil
+	}
+	snapped := position.Round(p.frameDuration)
+	if duration := p.controller.Duration(); duration > 0 {
+		snapped = min(snapped, duration)
+	}
+	return snapped, nil
+}
+
+// Sets whether [Player.Position]() should be rounded to the nearest frame
+// boundary, i.e., a multiple of the nominal frame duration. This is useful
+// for timeline UIs where the playhead must align with frame thumbnails.
+// Only the reported position is affected, the internal clocks and frame
+// scheduling keep using continuous positions. The default is false.
+//
+// Boundaries are based on the nominal frame rate, so variable frame rate
+// videos will snap to a regular grid rather than their actual frames; see
+// [Player.SetPositionSnapToPresentedFrame]() to snap to the frame on screen
+// instead. Audio-only media is never snapped.
+func (p *Player) SetPositionSnapToFrame(snap bool) {
+	p.snapToFrame = snap
+}
+
+// Sets whether [Player.Position]() should report the presentation offset
+// of the frame on screen, i.e., the last frame returned by
+// [Player.CurrentFrame](), instead of the playback clock. Unlike
+// [Player.SetPositionSnapToFrame](), this follows the actual frames of
+// variable frame rate videos, and it works for all controllers. The
+// default is false.
+//
+// The playback clock is still reported while stopped, before the first
+// frame is shown and once the end of the video is reached, where
+// [Player.SetPositionSnapToFrame]() applies if enabled. Audio-only media
+// is never snapped.
+func (p *Player) SetPositionSnapToPresentedFrame(snap bool) {
+	p.snapToPresented = snap
+}
+
+// Like [Player.Position](), but ignoring [Player.SetMonotonicPosition]()
+// and without the end-of-video detection side effects. For videos without
+// audio, this is the same as [Player.Position]().
+func (p *Player) RawPosition() (time.Duration, error) {
+	if p.closed {
+		return 0, ErrPlayerClosed
+	}
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if !isVideoWithAudio {
+		return p.controller.Position()
+	}
+	return controller.RawPosition()
+}
+
+// When enabled, [Player.Position]() will never go backwards during
+// continuous playback, except after stopping, seeking or looping. This
+// helps avoid UI elements like scrubber bars twitching due to the small
+// jitter of the audio clock used for videos with audio. The raw value
+// can still be obtained through [Player.RawPosition]().
+//
+// Videos without audio use a monotonic clock already, so this method
+// has no effect on them.
+func (p *Player) SetMonotonicPosition(monotonic bool) {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if isVideoWithAudio {
+		controller.SetMonotonicPosition(monotonic)
+	}
+}
+
+// Returns whether monotonic positions are enabled. See
+// [Player.SetMonotonicPosition]() for details.
+func (p *Player) GetMonotonicPosition() bool {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if !isVideoWithAudio {
+		return false
+	}
+	return controller.GetMonotonicPosition()
+}
+
+// Sets the clock reported by [Player.Position]() for videos with audio. By
+// default, the audio playback clock is used ([PositionAudioClock]). With
+// [PositionVideoFrame], the presentation offset of the current video frame is
+// reported instead, which is preferable for frame accurate UIs on files where
+// the audio and video timestamps don't match well. Frames are still selected
+// based on the audio clock, so audio sync is not affected.
+//
+// Videos without audio use a single wall clock that frames follow directly,
+// so this method has no effect on them.
+func (p *Player) SetPositionSource(source PositionSource) {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if isVideoWithAudio {
+		controller.SetPositionSource(source)
+	}
+}
+
+// Returns the clock reported by [Player.Position](). See
+// [Player.SetPositionSource]() for details.
+func (p *Player) GetPositionSource() PositionSource {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if !isVideoWithAudio {
+		return PositionAudioClock
+	}
+	return controller.GetPositionSource()
+}
+
+// Returns whether [Player.Seek]() is supported. This is false for live
+// streams. Useful to decide whether to show a timeline in the UI.
+func (p *Player) IsSeekable() bool {
+	return p.controller.IsSeekable()
+}
+
+// Returns whether the player is playing a live stream, as created by
+// [NewStreamPlayer]() and similar constructors. Live streams have no
+// duration, can't seek and can't loop. See also [Player.IsSeekable]().
+func (p *Player) IsLive() bool {
+	_, isStream := p.controller.(*streamVideoController)
+	return isStream
 }
 
 // Returns the video duration.
@@ -228,6 +1301,46 @@ func (p *Player) Duration() time.Duration {
 	return p.controller.Duration()
 }
 
+// Returns the remaining playback time, clamped to be non-negative. This
+// is 0 once the video has reached the end, and also for live streams,
+// which have no known duration.
+func (p *Player) Remaining() (time.Duration, error) {
+	if p.closed {
+		return 0, ErrPlayerClosed
+	}
+	duration := p.controller.Duration()
+	if duration <= 0 {
+		return 0, nil
+	}
+	position, err := p.controller.Position()
+	if err != nil {
+		return 0, err
+	}
+	return max(duration-position, 0), nil
+}
+
+// Returns the playback progress as a fraction in [0, 1], which is
+// convenient for progress bars. Once the video has reached the end, this
+// is 1. Live streams and media without a known duration always report 0,
+// as do closed players or players whose position can't be obtained.
+func (p *Player) Progress() float64 {
+	if p.closed {
+		return 0
+	}
+	duration := p.controller.Duration()
+	if duration <= 0 {
+		return 0
+	}
+	if p.reachedEnd {
+		return 1
+	}
+	position, err := p.controller.Position()
+	if err != nil {
+		return 0
+	}
+	return min(max(float64(position)/float64(duration), 0), 1)
+}
+
 // --- audio ---
 
 // Returns whether the video has audio.
@@ -245,7 +1358,9 @@ func (p *Player) GetVolume() float64 {
 	return controller.GetVolume()
 }
 
-// Sets the volume of the video. If the video has no audio, this method will have no effect.
+// Sets the volume of the video, in [0, 1]. Values outside the range are
+// clamped. The volume is also scaled by the global [SetMasterVolume]().
+// If the video has no audio, this method will have no effect.
 func (p *Player) SetVolume(volume float64) {
 	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
 	if isVideoWithAudio {
@@ -253,6 +1368,22 @@ func (p *Player) SetVolume(volume float64) {
 	}
 }
 
+// Like [Player.GetVolume](), but in decibels. 0dB is the maximum volume,
+// and a zero volume is reported as negative infinity (math.Inf(-1)), which
+// is also the case for videos without audio. Muting doesn't affect the
+// result, like with [Player.GetVolume]().
+func (p *Player) GetVolumeDB() float64 {
+	return linearToDB(p.GetVolume())
+}
+
+// Like [Player.SetVolume](), but in decibels, which map better to perceived
+// loudness than linear values. 0dB is the maximum volume, -6dB is roughly
+// half the amplitude, and negative infinity (math.Inf(-1)) silences the video.
+// Positive values are clamped to 0dB. The volume is remembered while muted.
+func (p *Player) SetVolumeDB(db float64) {
+	p.SetVolume(dbToLinear(db))
+}
+
 // Returns whether the video is muted or not. If the video has no audio,
 // true will be returned.
 func (p *Player) GetMuted() bool {
@@ -272,14 +1403,428 @@ func (p *Player) SetMuted(muted bool) {
 	}
 }
 
+// Sets the duration of the fade applied by [Player.SetMuted](), which avoids
+// the clicks that sudden volume changes can cause. The default is 0, which
+// mutes and unmutes instantly. A few milliseconds (e.g. 10ms) are typically
+// enough. Since audio is decoded ahead of time, fades might take a few dozen
+// milliseconds to start. If the video has no audio, this method will have
+// no effect.
+func (p *Player) SetMuteFadeDuration(fade time.Duration) {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if isVideoWithAudio {
+		controller.SetMuteFadeDuration(fade)
+	}
+}
+
+// Returns the duration of the mute fade. See [Player.SetMuteFadeDuration]().
+// If the video has no audio, 0 will be returned.
+func (p *Player) GetMuteFadeDuration() time.Duration {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if !isVideoWithAudio {
+		return 0
+	}
+	return controller.GetMuteFadeDuration()
+}
+
+// Returns the stereo pan of the video, in [-1, +1]. If the video has no
+// audio, 0 will be returned.
+func (p *Player) GetPan() float64 {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if !isVideoWithAudio {
+		return 0
+	}
+	return controller.GetPan()
+}
+
+// Sets the stereo pan of the video, where -1 is full left, 0 is centered
+// and +1 is full right. Values outside the range are clamped. Panning
+// attenuates the opposite channel, it doesn't move audio between channels.
+//
+// Since audio is decoded ahead of time, changes might take a few dozen
+// milliseconds to become audible. If the video has no audio, this method
+// will have no effect.
+func (p *Player) SetPan(pan float64) {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if isVideoWithAudio {
+		controller.SetPan(pan)
+	}
+}
+
+// Returns whether the left and right audio channels are being swapped.
+// See [Player.SetStereoSwap]().
+func (p *Player) GetStereoSwap() bool {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if !isVideoWithAudio {
+		return false
+	}
+	return controller.GetStereoSwap()
+}
+
+// Sets whether the left and right audio channels should be swapped,
+// which fixes videos with reversed channels without re-encoding them.
+// The swap is applied before panning and any other audio processing.
+// Mono audio is played with identical channels, so it's unaffected.
+//
+// Since audio is decoded ahead of time, changes might take a few dozen
+// milliseconds to become audible. If the video has no audio, this method
+// will have no effect.
+func (p *Player) SetStereoSwap(swap bool) {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if isVideoWithAudio {
+		controller.SetStereoSwap(swap)
+	}
+}
+
+// Returns the bass gain of the video, in decibels. If the video has no
+// audio, 0 will be returned.
+func (p *Player) GetBassGain() float64 {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if !isVideoWithAudio {
+		return 0
+	}
+	return controller.GetBassGain()
+}
+
+// Sets the bass gain of the video, in decibels. The gain is applied
+// through a low shelving filter around 250Hz. Positive values boost the
+// bass, negative values cut it, and 0 disables the filter. Values are
+// clamped to [-24, +24].
+//
+// Boosting can lead to clipping on loud audio, so it's often a good
+// idea to lower the volume when doing so. If the video has no audio,
+// this method will have no effect.
+func (p *Player) SetBassGain(gainDB float64) {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if isVideoWithAudio {
+		controller.SetBassGain(gainDB)
+	}
+}
+
+// Returns the treble gain of the video, in decibels. If the video has no
+// audio, 0 will be returned.
+func (p *Player) GetTrebleGain() float64 {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if !isVideoWithAudio {
+		return 0
+	}
+	return controller.GetTrebleGain()
+}
+
+// Sets the treble gain of the video, in decibels. The gain is applied
+// through a high shelving filter around 4kHz. A strong negative treble
+// gain (e.g. -18dB) combined with a slight bass boost makes audio sound
+// muffled, like heard through a wall. Values are clamped to [-24, +24],
+// and 0 disables the filter. If the video has no audio, this method will
+// have no effect.
+func (p *Player) SetTrebleGain(gainDB float64) {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if isVideoWithAudio {
+		controller.SetTrebleGain(gainDB)
+	}
+}
+
+// An AudioProcessor can modify decoded audio samples in place before
+// they are played. Samples are interleaved 16-bit signed values (L16),
+// so for stereo audio even indices correspond to the left channel and
+// odd indices to the right one. The channel count is passed alongside
+// for completeness, but it's always 2 at the moment.
+//
+// Processors are called from the audio decoding path while internal
+// locks are held, so they must be fast and they must not call any
+// [Player] methods.
+type AudioProcessor func(samples []int16, channelCount int)
+
+// Sets a function to process each decoded audio frame before it's played,
+// which allows implementing custom effects. The built-in effects (bass,
+// treble and pan) are applied after the processor. Passing nil removes
+// the processor. See [AudioProcessor] for details.
+//
+// Since audio is decoded ahead of time, changes might take a few dozen
+// milliseconds to become audible. If the video has no audio, this method
+// will have no effect.
+func (p *Player) SetAudioProcessor(processor AudioProcessor) {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if isVideoWithAudio {
+		controller.SetAudioProcessor(processor)
+	}
+}
+
+// Sets a writer that receives a copy of the decoded audio as it's played,
+// which allows recording it or streaming it elsewhere. The data is 16-bit
+// little endian signed stereo PCM, at the sample rate of the audio context,
+// with all the audio effects applied, but before muting and volume. Passing
+// nil removes the sink.
+//
+// The audio keeps flowing through ebitengine and driving the playback
+// clock, so to only capture the audio, mute the player with
+// [Player.SetMuted](): the sink still receives the unmuted data.
+//
+// The writer is called from the ebitengine audio goroutine with internal
+// locks held, so it must be fast and it must not call any [Player] methods.
+// If a write fails, the error is sent to the error handler (see
+// [Player.OnError]()) and the sink is removed. If the video has no audio,
+// this method will have no effect.
+func (p *Player) SetAudioSink(w io.Writer) {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if isVideoWithAudio {
+		controller.SetAudioSink(w)
+	}
+}
+
+// Returns how much audio is buffered ahead of the current playback
+// position, which can help diagnose A/V sync issues: values close to
+// zero indicate audio starvation, while large values indicate overbuffering.
+//
+// The result includes audio already decoded but not yet requested by
+// ebitengine, and the nominal size of the ebitengine audio player buffer
+// while the audio player is active (its actual fill level is not exposed).
+// If the video has no audio, 0 will be returned.
+func (p *Player) BufferedAudioDuration() time.Duration {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if !isVideoWithAudio {
+		return 0
+	}
+	return controller.BufferedAudioDuration()
+}
+
+// Returns the size of the ebitengine audio player buffer. If the video
+// has no audio, 0 will be returned.
+func (p *Player) GetAudioBufferSize() time.Duration {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if !isVideoWithAudio {
+		return 0
+	}
+	return controller.GetAudioBufferSize()
+}
+
+// Sets the size of the ebitengine audio player buffer. Smaller buffers
+// reduce latency, but values that are too small will cause audio glitches.
+// See [PlayerOptions].AudioBufferSize for reference values. Zero or negative
+// values restore the default. If the video has no audio, this method will
+// have no effect.
+func (p *Player) SetAudioBufferSize(bufferSize time.Duration) {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if isVideoWithAudio {
+		controller.SetAudioBufferSize(bufferSize)
+	}
+}
+
+// Returns the current audio level of the left and right channels, in [0, 1].
+// Levels are computed as the RMS of the audio recently handed to the audio
+// output (in windows of about 20ms) and take the volume into account, so
+// they can be used for VU meters or audio reactive visuals. Notice that the
+// levels can lead what's actually heard by up to [Player.GetAudioBufferSize]().
+// Zero is returned while the video is not playing, while muted, or if the
+// video has no audio.
+func (p *Player) AudioLevel() (left, right float64) {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if !isVideoWithAudio {
+		return 0, 0
+	}
+	return controller.AudioLevel()
+}
+
+// Returns the magnitudes of the given amount of frequency bands, computed
+// with an FFT over the audio recently handed to the audio output. Bands are
+// logarithmically spaced from 20Hz up to half the sample rate, and magnitudes
+// are roughly normalized to [0, 1]. This is useful for spectrum analyzers and
+// music visualizations. See [Player.SetSpectrumConfig]() for configuration.
+//
+// Audio is only captured for the spectrum after the first call, so the first
+// results will be zeros. The volume is not taken into account. If the video
+// has no audio or bands is not positive, nil is returned.
+func (p *Player) AudioSpectrum(bands int) []float64 {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if !isVideoWithAudio || bands <= 0 {
+		return nil
+	}
+	return controller.AudioSpectrum(bands)
+}
+
+// Configures [Player.AudioSpectrum](). The window size is the amount of
+// samples analyzed, rounded up to a power of two: larger windows give
+// better frequency resolution, but react slower and cost more (default
+// 2048). The smoothing in [0, 1) determines how much of the previous
+// results is kept on each call, which avoids jittery visuals (default 0.5).
+// If the video has no audio, this method will have no effect.
+func (p *Player) SetSpectrumConfig(windowSize int, smoothing float64) {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if isVideoWithAudio {
+		controller.SetSpectrumConfig(windowSize, smoothing)
+	}
+}
+
+// Returns the audio/video synchronization offset. See [Player.SetAVSyncOffset]().
+func (p *Player) GetAVSyncOffset() time.Duration {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if !isVideoWithAudio {
+		return 0
+	}
+	return controller.GetAVSyncOffset()
+}
+
+// Shifts the video presentation relative to the audio, in order to
+// compensate for output latencies that make audio and video go out
+// of sync on specific systems. Positive offsets delay the video, and
+// negative offsets advance it. Only the frames selected by
+// [Player.CurrentFrame]() are affected, not the reported [Player.Position]().
+// If the video has no audio, this method will have no effect.
+func (p *Player) SetAVSyncOffset(offset time.Duration) {
+	controller, isVideoWithAudio := p.controller.(*videoWithAudioController)
+	if isVideoWithAudio {
+		controller.SetAVSyncOffset(offset)
+	}
+}
+
 // --- looping ---
 
 func (p *Player) SetLooping(looping bool) {
+	if p.seamlessLoop {
+		if looping {
+			return
+		}
+		p.seamlessLoop = false
+		_ = p.closeLoopSpare()
+	}
 	p.controller.SetLooping(looping)
 }
 
 func (p *Player) GetLooping() bool {
-	return p.controller.GetLooping()
+	return p.seamlessLoop || p.controller.GetLooping()
+}
+
+// Sets a function to be called each time a looping video wraps around to
+// its loop start, with the amount of loops completed since the playback
+// last started from the beginning (1 for the first wrap). Seeking doesn't
+// count as looping, and stopping or restarting the player resets the count.
+// Passing nil removes the callback.
+//
+// Like position triggers, loops are detected on [Player.CurrentFrame]()
+// calls, on the calling goroutine, so the callback can be delayed by up
+// to one update.
+func (p *Player) OnLoop(fn func(loopCount int)) {
+	p.onLoop = fn
+}
+
+// Sets a function to be called when [Player.CurrentFrame]() returns the
+// first video frame after the playback starts, which is the moment that
+// frame becomes visible to the caller, not merely when it's decoded. This
+// is mainly useful for live streams, where connecting and buffering can
+// take a while, e.g. to hide a loading indicator at the right time.
+//
+// The callback fires once per playback session: stopping the player, or
+// playing it again after it reached the end, makes the next first frame
+// fire it again. Pausing, seeking and looping don't. Like [Player.OnLoop](),
+// it's called on the goroutine calling CurrentFrame(). Passing nil removes
+// the callback.
+func (p *Player) OnFirstFrame(fn func()) {
+	p.onFirstFrame = fn
+}
+
+// Implemented by the package controllers to count loops, see [Player.OnLoop]().
+// Custom [VideoController] implementations don't need to implement it.
+type loopCounter interface {
+	// Returns the total amount of times the video has looped back to the
+	// loop start since the controller was created. Manual seeks don't count.
+	LoopCount() int
+}
+
+// Returns the loop count of the given controller, or 0 if it doesn't
+// count loops.
+func controllerLoopCount(controller VideoController) int {
+	if counter, ok := controller.(loopCounter); ok {
+		return counter.LoopCount()
+	}
+	return 0
+}
+
+// Restarts the count of completed loops, discarding any pending loops.
+func (p *Player) resetLoops() {
+	p.loopsSeen = controllerLoopCount(p.controller)
+	p.completedLoops = 0
+}
+
+// Counts the loops since the last evaluation and calls the loop callback.
+func (p *Player) updateLoops() {
+	loops := controllerLoopCount(p.controller)
+	newLoops := loops - p.loopsSeen
+	p.loopsSeen = loops
+	for range max(newLoops, 0) {
+		p.completedLoops += 1
+		if p.onLoop != nil {
+			p.onLoop(p.completedLoops)
+		}
+	}
+}
+
+// Sets what happens when the video reaches the end without looping. By default,
+// the video is stopped and the decoder closed ([EndStopAndClose]). With
+// [EndPauseAtEnd], the player is left [Paused] at the end instead, with the
+// last frame still visible and the decoder open, so seeking backwards is still
+// possible. Live streams don't have an end, so they ignore this setting.
+// Custom controllers (see [NewPlayerWithController]()) ignore it too, unless
+// they implement a SetEndBehavior(EndBehavior) method.
+func (p *Player) SetEndBehavior(behavior EndBehavior) {
+	p.endBehavior = behavior
+	p.applyEndBehavior(p.controller)
+	if p.loopSpare != nil {
+		p.applyEndBehavior(p.loopSpare)
+	}
+}
+
+// Implemented by the package controllers to handle the end of the video
+// according to [Player.SetEndBehavior](). Custom [VideoController]
+// implementations don't need to implement it.
+type endBehaviorSetter interface {
+	SetEndBehavior(EndBehavior)
+}
+
+// Applies the player's end behavior to the given controller, if supported.
+func (p *Player) applyEndBehavior(controller VideoController) {
+	if setter, ok := controller.(endBehaviorSetter); ok {
+		setter.SetEndBehavior(p.endBehavior)
+	}
+}
+
+// Sets the position the video returns to when looping, which is 0 by
+// default. This is useful for videos with an intro followed by a loopable
+// section: the first playthrough starts at 0 as usual, but subsequent
+// loops return to the loop start instead. The position is clamped to
+// leave at least one frame before the end of the video. Live streams
+// can't loop, so this method has no effect on them.
+func (p *Player) SetLoopStart(position time.Duration) {
+	p.controller.SetLoopStart(position)
+	if p.loopSpare != nil {
+		p.loopSpare.SetLoopStart(position)
+		_, err := p.loopSpare.Seek(p.loopSpare.GetLoopStart())
+		if err != nil { // prepared again when needed
+			_ = p.closeLoopSpare()
+		}
+	}
+}
+
+// Returns the position the video returns to when looping. See
+// [Player.SetLoopStart]() for details.
+func (p *Player) GetLoopStart() time.Duration {
+	return p.controller.GetLoopStart()
+}
+
+// Sets a function to be called with errors that happen in the background,
+// outside of any [Player] method call. This is mainly relevant for live
+// streams, where decoding errors are not fatal and would otherwise go
+// unnoticed, but it also reports fatal audio decoding errors on videos
+// with audio (see [Player.Error]()). Errors returned by method calls are
+// not reported again through this handler.
+//
+// The handler may be called from other goroutines, but never while the
+// player is holding internal locks, so it's safe to call player methods
+// from it. Passing nil removes the handler.
+func (p *Player) OnError(handler func(error)) {
+	p.errorHandler = handler
+	p.controller.SetErrorHandler(handler)
+	if p.loopSpare != nil {
+		p.loopSpare.SetErrorHandler(handler)
+	}
 }
 
 func (p *Player) Error() error {
@@ -293,9 +1838,25 @@ func (p *Player) Error() error {
 // but the resources are allocated through cgo, so if possible, use this method.
 // This should be treated like a C free() operation.
 //
+// Once closed, methods that can fail return [ErrPlayerClosed], even if
+// closing itself failed, as the resources can't be safely released twice.
+//
 // Do not confuse with [Player.Stop]().
 func (p *Player) Close() error {
-	return p.controller.Close()
+	if p.closed {
+		return ErrPlayerClosed
+	}
+	p.closed = true
+	p.cancelSeeks()
+	err := errors.Join(p.controller.Close(), p.closeFrameCache(), p.closeLoopSpare())
+	if err != nil {
+		return err
+	}
+	if p.usesNetwork {
+		p.usesNetwork = false
+		return reisen.NetworkDeinitialize()
+	}
+	return nil
 }
 
 // Moves the player's playback position to the given one, relative to the start
@@ -303,31 +1864,410 @@ func (p *Player) Close() error {
 //
 // The precision of the method is not well explored, and it might depend on the
 // amount of inter-frames encoded in the video.
+//
+// Seeking while the video is [Stopped] leaves it [Paused] at the given position,
+// so a later [Player.Play]() resumes from there. This also applies after the
+// video has ended: the decoder is reopened transparently, and
+// [Player.HasEnded]() returns false again. Seeking to or past the end of the
+// video always stops it instead.
+//
+// For videos with audio, seeking while playing restarts the audio output,
+// which can cause a short audible gap. Small seeks can avoid decoding again
+// with [PlayerOptions].SeekBufferDuration, which reduces the gap.
+//
+// Seeks are coalesced: this method only records the target position, and
+// the seek is performed by the next call to a method retrieving frames (like
+// [Player.CurrentFrame]()), [Player.Position](), [Player.State]() or
+// [Player.Play](), so when seeking multiple times in between (e.g. while
+// scrubbing quickly), only the latest target is honored. Errors found while
+// seeking are returned by that call. [Player.Stop]() discards the target.
+// Unlike other player methods, Seek() can also be called from goroutines
+// other than the one updating the player, e.g. an input handler, but not
+// concurrently with [Player.Close](). See [Player.SeekAsync]() for seeking
+// without blocking the goroutine updating the player.
 func (p *Player) Seek(position time.Duration) error {
+	if p.closed {
+		return ErrPlayerClosed
+	}
+	p.pendingSeekMutex.Lock()
+	p.seekGeneration.Add(1) // supersedes pending asynchronous seeks
+	p.pendingSeek = position
+	p.hasPendingSeek = true
+	p.pendingSeekMutex.Unlock()
+	return nil
+}
+
+// Performs the seek recorded by the last Seek() call, if any. See Seek().
+func (p *Player) applyPendingSeek() error {
+	p.pendingSeekMutex.Lock()
+	position, pending := p.pendingSeek, p.hasPendingSeek
+	p.hasPendingSeek = false
+	p.pendingSeekMutex.Unlock()
+	if !pending {
+		return nil
+	}
+
+	p.seekMutex.Lock()
 	frame, err := p.controller.Seek(position)
+	p.seekMutex.Unlock()
+	if err != nil {
+		return err
+	}
+	return p.finishSeek(frame, position)
+}
+
+// Discards the seek recorded by the last Seek() call, if any.
+func (p *Player) discardPendingSeek() {
+	p.pendingSeekMutex.Lock()
+	p.hasPendingSeek = false
+	p.pendingSeekMutex.Unlock()
+}
+
+// Updates the player state after the controller has been moved to the
+// given position, presenting the frame it landed on.
+func (p *Player) finishSeek(frame *reisen.VideoFrame, position time.Duration) error {
+	if p.peekState() != Stopped {
+		// seeking back into an ended video, it's no longer at the end
+		p.reachedEnd = false
+		p.keepStoppedFrame = false
+	}
+	return p.presentSeekFrame(frame, position)
+}
+
+// Replaces the video being played with the given file, continuing from the
+// current position and keeping the playing or paused state. This allows
+// switching between different versions of the same content, like different
+// resolutions, without a visible restart. The new file is opened with the
+// same [PlayerOptions] as the original one, and the looping configuration
+// and [Player.OnError]() handler are preserved. Other settings are reset to
+// their defaults. The previous video is closed.
+//
+// If the resolution of the new file is different, [Player.CurrentFrame]()
+// will return a new image from now on. The frame rate is also taken from
+// the new file, unless overridden with [Player.SetFrameRateOverride]().
+// Switching requires seeking, so it's not supported for live streams nor
+// for sources where [Player.IsSeekable]() would be false, except while the
+// player is [Stopped].
+func (p *Player) SwitchSource(videoFilename string) error {
+	return p.switchSource(videoFilename, false)
+}
+
+// Implements [Player.SwitchSource](). If keepAudioConfig is true, the audio
+// configuration of the current controller (volume, mute, etc.) is carried
+// over to the new one before it starts playing.
+func (p *Player) switchSource(videoFilename string, keepAudioConfig bool) error {
+	if p.closed {
+		return ErrPlayerClosed
+	}
+	if p.IsLive() {
+		return fmt.Errorf("cannot switch the source of a live stream")
+	}
+	if err := p.applyPendingSeek(); err != nil {
+		return err
+	}
+	p.cancelSeeks()
+
+	state, err := p.controller.State()
+	if err != nil {
+		return err
+	}
+	position, err := p.controller.Position()
 	if err != nil {
 		return err
 	}
 
-	p.copyFrame(frame)
+	// create and set up the new controller
+	name := filepath.Base(videoFilename)
+	container, err := reisen.NewMedia(videoFilename)
+	if err != nil {
+		return err
+	}
+	controller, videoStream, err := newController(container, name, p.options, nil, p.logger)
+	if err != nil {
+		container.Close()
+		return err
+	}
+	if state != Stopped && !controller.IsSeekable() {
+		_ = controller.Close()
+		return fmt.Errorf("cannot switch to '%s' while playing, as it doesn't support seeking", name)
+	}
+	controller.SetLooping(p.controller.GetLooping())
+	controller.SetLoopStart(p.controller.GetLoopStart())
+	controller.SetErrorHandler(p.errorHandler)
+	p.applyEndBehavior(controller)
+	p.applyFrameDurationOverride(controller)
+	if keepAudioConfig {
+		prev, prevHasAudio := p.controller.(*videoWithAudioController)
+		next, nextHasAudio := controller.(*videoWithAudioController)
+		if prevHasAudio && nextHasAudio {
+			next.copyConfigFrom(prev)
+		}
+	}
+
+	var frame *reisen.VideoFrame
+	if state != Stopped {
+		frame, err = controller.Seek(position)
+		if err == nil && frame != nil && state == Playing {
+			err = controller.Play()
+		}
+		if err != nil {
+			_ = controller.Close()
+			return err
+		}
+	}
+
+	// swap controllers and release the previous one
+	prevController := p.controller
+	p.controller = controller
+	var width, height int // (0, 0) for audio-only media
+	if videoStream != nil {
+		width, height = decodeResolution(videoStream, p.options)
+	}
+	if currWidth, currHeight := p.Resolution(); width != currWidth || height != currHeight {
+		p.currentFrame = nil
+		if videoStream != nil {
+			p.currentFrame = ebiten.NewImage(width, height)
+			p.currentFrame.Fill(p.getPlaceholderColor())
+		}
+		p.onBlackFrame = true
+		p.framePixels = nil
+		p.currentFrameStale = false
+		p.frameVersion += 1
+	}
+	err = errors.Join(prevController.Close(), p.closeFrameCache())
+
+	// refresh the frame rate, keeping the override if any. audio-only
+	// media doesn't have frames, so the override doesn't apply to it
+	p.detectedFrameRate = frameRate{}
+	p.detectedFrameDuration = 0
+	if videoStream != nil {
+		p.detectedFrameRate = nominalFrameRate(videoStream)
+		p.detectedFrameDuration = nominalFrameDuration(videoStream)
+		p.applyFrameDuration(p.effectiveFrameDuration())
+	} else {
+		p.applyFrameDuration(0)
+	}
+	p.source = videoFilename
+	p.accurateDuration = 0
+	p.loopsSeen = controllerLoopCount(controller)
+	p.audioTracks = playerAudioTracks(container, controller, p.options, nil)
+	p.containerInfo = containerInfoOf(container)
+	p.streams = streamInfos(container)
+	p.keyframes = nil
+	if p.seamlessLoop {
+		err = errors.Join(err, p.closeLoopSpare(), p.prepareLoopSpare())
+	}
+	if p.usesNetwork {
+		p.usesNetwork = false
+		err = errors.Join(err, reisen.NetworkDeinitialize())
+	}
+	if err != nil {
+		return err
+	}
+
+	if state == Stopped {
+		return nil
+	}
+	return p.presentSeekFrame(frame, position)
+}
+
+// Creates a new player for the same file or URL, with its own decoder, and
+// the same configuration as this one: [PlayerOptions], looping, seamless
+// looping, loop start, end behavior, frame rate override, target FPS, position
+// snapping, frame processing, chroma key, color adjustments, frame cache size,
+// error handler and, for videos with audio, volume, mute, mute fade, pan,
+// stereo swap, equalizer gains, monotonic position, position source, audio
+// buffer size and A/V sync offset. Audio processors, position triggers and
+// loop callbacks are not copied, as they often keep per-player state. The
+// clone starts [Stopped] at position 0, regardless of the state of this player.
+//
+// The source must be known, so this is only available for players created
+// from a file or URL, and not for live streams nor players created from
+// existing media or custom controllers.
+func (p *Player) Clone() (*Player, error) {
+	if p.closed {
+		return nil, ErrPlayerClosed
+	}
+	if p.source == "" {
+		return nil, fmt.Errorf("can't clone: %w", ErrNoSource)
+	}
+
+	if p.usesNetwork {
+		err := reisen.NetworkInitialize()
+		if err != nil {
+			return nil, err
+		}
+	}
+	clone, err := newPlayer(p.source, p.options, nil)
+	if err != nil {
+		if p.usesNetwork {
+			_ = reisen.NetworkDeinitialize()
+		}
+		return nil, err
+	}
+	clone.usesNetwork = p.usesNetwork
+
+	// player configuration
+	clone.SetLooping(p.GetLooping())
+	clone.SetLoopStart(p.GetLoopStart())
+	clone.SetEndBehavior(p.endBehavior)
+	clone.SetTargetFPS(p.targetFPS)
+	clone.SetPositionSnapToFrame(p.snapToFrame)
+	clone.SetPositionSnapToPresentedFrame(p.snapToPresented)
+	clone.SetPlaceholderColor(p.placeholderColor)
+	clone.SetPauseOnUnfocused(p.pauseOnUnfocused)
+	clone.OnError(p.errorHandler)
+	clone.frameProcessor = p.frameProcessor
+	clone.chromaKey = p.chromaKey
+	clone.colorAdjust = p.colorAdjust
+	clone.SetPremultiplyAlpha(p.premultiplyAlpha)
+	clone.frameCache.capacity = p.frameCache.capacity
+	if p.frameRateOverride.num > 0 {
+		err = clone.SetFrameRateOverride(p.frameRateOverride.num, p.frameRateOverride.denom)
+		if err != nil {
+			return nil, errors.Join(err, clone.Close())
+		}
+	}
+	if p.seamlessLoop {
+		// after the settings above, as they also apply to the spare
+		err = clone.SetSeamlessLoop(true)
+		if err != nil {
+			return nil, errors.Join(err, clone.Close())
+		}
+	}
+
+	// audio configuration (no-ops if there's no audio)
+	clone.SetVolume(p.GetVolume())
+	clone.SetMuted(p.GetMuted())
+	clone.SetMuteFadeDuration(p.GetMuteFadeDuration())
+	clone.SetPan(p.GetPan())
+	clone.SetStereoSwap(p.GetStereoSwap())
+	clone.SetBassGain(p.GetBassGain())
+	clone.SetTrebleGain(p.GetTrebleGain())
+	clone.SetMonotonicPosition(p.GetMonotonicPosition())
+	clone.SetPositionSource(p.GetPositionSource())
+	clone.SetAudioBufferSize(p.GetAudioBufferSize())
+	clone.SetAVSyncOffset(p.GetAVSyncOffset())
+	return clone, nil
+}
+
+// Updates the current frame after a seek to the given position.
+func (p *Player) presentSeekFrame(frame *reisen.VideoFrame, position time.Duration) error {
+	if frame == nil { // seeking past the end stops the video
+		p.currentPresOffset = 0
+		p.triggerPosition = triggerPositionReset
+		p.clearFrame()
+		return nil
+	}
+	p.triggerPosition = max(position, 0) // seeks don't fire triggers
+
 	start, err := frame.PresentationOffset()
 	if err != nil {
 		panic(err)
 	}
 	p.currentPresOffset = start
-	return nil
+	return p.copyFrame(frame)
 }
 
 // --- internal ---
 
-func (p *Player) copyFrame(frame *reisen.VideoFrame) {
-	if frame == nil {
-		if !p.onBlackFrame {
-			p.currentFrame.Fill(color.Black)
-			p.onBlackFrame = true
-		}
+func (p *Player) clearFrame() {
+	if !p.onBlackFrame && p.currentFrame != nil {
+		p.currentFrame.Fill(p.getPlaceholderColor())
+		p.onBlackFrame = true
+		p.framePixels = nil
+		p.currentFrameStale = false
+		p.frameVersion += 1
+	}
+}
+
+// Returns p.currentFrame, writing the latest frame pixels to it first if
+// they were only written to a [Player.CurrentFrameInto]() destination.
+func (p *Player) syncedFrame() *ebiten.Image {
+	if p.currentFrameStale {
+		p.currentFrame.WritePixels(p.framePixels)
+		p.currentFrameStale = false
+	}
+	return p.currentFrame
+}
+
+// Writes the given non-nil frame to p.currentFrame, or p.frameTarget if
+// set, applying any frame processing on the way. Returns [ErrBadFrameData]
+// if the frame data doesn't match the expected size.
+func (p *Player) copyFrame(frame *reisen.VideoFrame) error {
+	pixels := frame.Data()
+	if err := p.checkFrameData(pixels); err != nil {
+		return err
+	}
+
+	if p.frameProcessor != nil || p.chromaKey.enabled || p.colorAdjust.enabled || p.premultiplyAlpha {
+		// work on a copy, as the controller might keep the frame around
+		p.frameBuffer = append(p.frameBuffer[:0], pixels...)
+		pixels = p.frameBuffer
+		p.processPixels(pixels)
+	}
+	if p.frameTarget != nil {
+		p.frameTarget.WritePixels(pixels)
+		p.currentFrameStale = true
 	} else {
-		p.currentFrame.WritePixels(frame.Data())
-		p.onBlackFrame = false
+		p.currentFrame.WritePixels(pixels)
+		p.currentFrameStale = false
+	}
+	p.framePixels = pixels
+	p.onBlackFrame = false
+	p.frameVersion += 1
+	return nil
+}
+
+// Shows the first frame of the video while stopped, decoding it with
+// the frame peeker. See PlayerOptions.ShowFirstFrameWhenStopped.
+func (p *Player) showFirstFrame() error {
+	if err := p.ensurePeeker(); err != nil {
+		return err
+	}
+	frame, err := p.peeker.frameAt(0)
+	if err != nil || frame == nil {
+		return err
+	}
+	presOffset, err := frame.PresentationOffset()
+	if err != nil {
+		return err
+	}
+	p.currentPresOffset = presOffset
+	return p.copyFrame(frame)
+}
+
+// Returns whether the frame with the given presentation offset must be
+// skipped to respect the target fps. Frames going back in time (e.g. due
+// to looping) are never skipped.
+func (p *Player) isDecimatedFrame(presOffset time.Duration) bool {
+	if p.targetFPS == 0 || p.onBlackFrame || presOffset < p.currentPresOffset {
+		return false
+	}
+	return presOffset < p.currentPresOffset+time.Second/time.Duration(p.targetFPS)
+}
+
+// Returns [ErrBadFrameData] if the given frame data doesn't match the
+// expected size for the video resolution.
+func (p *Player) checkFrameData(pixels []byte) error {
+	width, height := p.Resolution()
+	expectedLen := width * height * frameBytesPerPixel
+	if len(pixels) != expectedLen {
+		return fmt.Errorf("%w: got %d bytes, expected %d (%dx%d %s)", ErrBadFrameData, len(pixels), expectedLen, width, height, PixelFormatRGBA)
+	}
+	return nil
+}
+
+// Applies the chroma key, the color adjustments, the frame processor and
+// the alpha premultiplication to the given pixels, in place.
+func (p *Player) processPixels(pixels []byte) {
+	p.chromaKey.apply(pixels)
+	p.colorAdjust.apply(pixels)
+	if p.frameProcessor != nil {
+		width, height := p.Resolution()
+		p.frameProcessor(pixels, width, height)
+	}
+	if p.premultiplyAlpha {
+		premultiplyAlpha(pixels)
 	}
 }
@@ -0,0 +1,164 @@
+package avebi
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Returned by [Playlist.Next]() and [Playlist.PrefetchNext]() when the
+// current video is the last one of the playlist.
+var ErrPlaylistEnd = errors.New("no more videos in the playlist")
+
+// A Playlist plays a sequence of video files, one at a time. Players are
+// created on demand, but the next player can be prepared ahead of time with
+// [Playlist.PrefetchNext]() so switching to it doesn't have any construction
+// nor decoding latency, which allows for practically gapless transitions.
+//
+// The playlist owns its players: they must not be closed directly, and
+// [Playlist.Close]() must be used to release them instead.
+type Playlist struct {
+	sources []string
+	opts    PlayerOptions
+	index   int
+	current *Player
+	next    *Player // prefetched player for index+1, if any
+}
+
+// Creates a playlist for the given files, with the given options for all
+// the players, and opens the first video, leaving it [Stopped].
+func NewPlaylist(videoFilenames []string, opts PlayerOptions) (*Playlist, error) {
+	if len(videoFilenames) == 0 {
+		return nil, fmt.Errorf("empty playlist")
+	}
+	player, err := NewPlayerWithOptions(videoFilenames[0], opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Playlist{
+		sources: append([]string(nil), videoFilenames...),
+		opts:    opts,
+		current: player,
+	}, nil
+}
+
+// Returns the amount of videos in the playlist.
+func (pl *Playlist) Len() int {
+	return len(pl.sources)
+}
+
+// Returns the index of the current video.
+func (pl *Playlist) Index() int {
+	return pl.index
+}
+
+// Returns the player for the current video. The player changes after
+// [Playlist.Next]() or [Playlist.JumpTo]() calls, so it shouldn't be stored.
+func (pl *Playlist) Current() *Player {
+	return pl.current
+}
+
+// Creates the player for the next video and decodes its first frame, so a
+// later [Playlist.Next]() can switch to it instantly. This is typically called
+// when the current video is approaching its end. If the next video is already
+// prefetched, it does nothing. Returns [ErrPlaylistEnd] on the last video.
+//
+// This is a blocking operation. Prefetched players are closed automatically
+// if they end up not being used (e.g. after jumping elsewhere).
+func (pl *Playlist) PrefetchNext() error {
+	if pl.current == nil {
+		return ErrPlayerClosed
+	}
+	if pl.next != nil {
+		return nil
+	}
+	if pl.index+1 >= len(pl.sources) {
+		return ErrPlaylistEnd
+	}
+
+	player, err := NewPlayerWithOptions(pl.sources[pl.index+1], pl.opts)
+	if err != nil {
+		return err
+	}
+	err = player.Prime()
+	if err != nil {
+		return errors.Join(err, player.Close())
+	}
+	pl.next = player
+	return nil
+}
+
+// Switches to the next video, using the prefetched player if available,
+// and closes the current one. If the current video was playing or had
+// reached its end, the next one starts playing right away. Returns
+// [ErrPlaylistEnd] on the last video.
+func (pl *Playlist) Next() error {
+	if pl.current == nil {
+		return ErrPlayerClosed
+	}
+	if pl.index+1 >= len(pl.sources) {
+		return ErrPlaylistEnd
+	}
+	err := pl.PrefetchNext()
+	if err != nil {
+		return err
+	}
+	return pl.switchTo(pl.index+1, pl.next)
+}
+
+// Switches to the video at the given index, closing the current player and
+// any prefetched one that is not needed anymore. If the current video was
+// playing or had reached its end, the new one starts playing right away.
+func (pl *Playlist) JumpTo(index int) error {
+	if pl.current == nil {
+		return ErrPlayerClosed
+	}
+	if index < 0 || index >= len(pl.sources) {
+		return fmt.Errorf("playlist index %d out of range [0, %d)", index, len(pl.sources))
+	}
+	if index == pl.index+1 && pl.next != nil {
+		return pl.switchTo(index, pl.next)
+	}
+
+	player, err := NewPlayerWithOptions(pl.sources[index], pl.opts)
+	if err != nil {
+		return err
+	}
+	return pl.switchTo(index, player)
+}
+
+// Makes the given player the current one, releasing the previous players.
+// This never fails before taking ownership of the given player, so callers
+// don't need to close it on error.
+func (pl *Playlist) switchTo(index int, player *Player) error {
+	// a current player that failed can't tell whether it was playing, but
+	// switching must still be possible, e.g. to skip a broken video
+	state, err := pl.current.State()
+	autoplay := err == nil && (state == Playing || pl.current.HasEnded())
+
+	var closeErr error
+	if pl.next != nil && pl.next != player {
+		closeErr = pl.next.Close()
+	}
+	closeErr = errors.Join(closeErr, pl.current.Close())
+	pl.current, pl.next, pl.index = player, nil, index
+
+	if autoplay {
+		return errors.Join(player.Play(), closeErr)
+	}
+	return closeErr
+}
+
+// Closes the current and prefetched players. The playlist becomes unusable
+// after this, and further calls return [ErrPlayerClosed].
+func (pl *Playlist) Close() error {
+	if pl.current == nil {
+		return ErrPlayerClosed
+	}
+	var err error
+	if pl.next != nil {
+		err = pl.next.Close()
+	}
+	err = errors.Join(err, pl.current.Close())
+	pl.current, pl.next = nil, nil
+	return err
+}
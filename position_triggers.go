@@ -0,0 +1,105 @@
+package avebi
+
+import "time"
+
+// Sentinel for the last evaluated trigger position, placed before
+// the start of the video so that triggers at position 0 can also fire.
+const triggerPositionReset time.Duration = -1
+
+type positionTrigger struct {
+	at time.Duration
+	fn func()
+}
+
+// Adds a function to be called once each time the playback position crosses
+// the given position. When looping, triggers fire again on each loop. This is
+// useful to synchronize events with the video, like showing captions during
+// cutscenes.
+//
+// Triggers are evaluated on [Player.CurrentFrame]() calls, on the calling
+// goroutine, so they can precede or follow the exact crossing by up to one
+// update. Seeking doesn't fire the triggers in between, and stopping the
+// player re-arms all triggers.
+func (p *Player) AddPositionTrigger(at time.Duration, fn func()) {
+	if fn == nil {
+		panic("nil trigger function")
+	}
+	p.triggers = append(p.triggers, positionTrigger{at: max(at, 0), fn: fn})
+}
+
+// Removes all the triggers added with [Player.AddPositionTrigger]().
+func (p *Player) ClearPositionTriggers() {
+	p.triggers = nil // not cleared in place, as it could be called from a trigger
+}
+
+// Fires the triggers crossed since the last evaluation.
+func (p *Player) updatePositionTriggers() error {
+	if len(p.triggers) == 0 {
+		return nil
+	}
+
+	position, err := p.controller.Position()
+	if err != nil {
+		return err
+	}
+
+	prevPosition := p.triggerPosition
+	p.triggerPosition = position
+	if position >= prevPosition {
+		p.firePositionTriggers(prevPosition, position)
+	} else if p.controller.GetLooping() {
+		// wrapped around: fire until the end, then from the loop start
+		p.firePositionTriggers(prevPosition, p.controller.Duration())
+		p.firePositionTriggers(p.controller.GetLoopStart()+triggerPositionReset, position)
+	}
+	return nil
+}
+
+// Fires the triggers in (from, to].
+func (p *Player) firePositionTriggers(from, to time.Duration) {
+	for _, trigger := range p.triggers {
+		if trigger.at > from && trigger.at <= to {
+			trigger.fn()
+		}
+	}
+}
+
+// Sets a function to be called with the current playback position at most
+// once per interval, which is useful to update position labels and progress
+// bars without querying [Player.Position]() on every update. Zero or negative
+// intervals call the function on every evaluation. The function is only
+// called when the position has changed since the previous call, so it stays
+// quiet while paused or stopped. Passing a nil function removes it.
+//
+// Like position triggers, updates are evaluated on [Player.CurrentFrame]()
+// calls, on the calling goroutine, and the interval is measured against
+// the monotonic clock.
+func (p *Player) OnPositionUpdate(interval time.Duration, fn func(position time.Duration)) {
+	p.onPositionUpdate = fn
+	p.positionUpdateInterval = max(interval, 0)
+	p.lastPositionUpdate = time.Time{}
+	p.positionUpdateValue = triggerPositionReset
+}
+
+// Calls the position update function if the interval has elapsed.
+func (p *Player) updatePositionCallback() error {
+	if p.onPositionUpdate == nil {
+		return nil
+	}
+	now := nowFunc()
+	if !p.lastPositionUpdate.IsZero() && now.Sub(p.lastPositionUpdate) < p.positionUpdateInterval {
+		return nil
+	}
+
+	position, err := p.Position()
+	if err != nil {
+		return err
+	}
+	if position == p.positionUpdateValue {
+		return nil
+	}
+	p.lastPositionUpdate = now
+	p.positionUpdateValue = position
+	p.onPositionUpdate(position)
+	return nil
+}
@@ -0,0 +1,261 @@
+package avebi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/erparts/reisen"
+)
+
+// Size of the canonical WAV header written by [Player.RecordTo]().
+const wavHeaderSize = 44
+
+// Writes decoded frames and audio to a directory, see [Player.RecordTo]().
+type recorder struct {
+	dir         string
+	start, end  time.Duration
+	frameCount  int
+	wav         *os.File // nil if there's no audio
+	sampleRate  int
+	audioBytes  int
+	videoActive bool // false once the video stream reaches the end of the window
+	audioActive bool // false once the audio stream reaches the end of the window
+}
+
+// Writes the given frame if it overlaps the recorded window, and returns
+// whether later frames can still overlap it.
+func (r *recorder) writeFrame(frame *reisen.VideoFrame, frameDuration time.Duration) (bool, error) {
+	presOffset, err := frame.PresentationOffset()
+	if err != nil {
+		return false, err
+	}
+	if presOffset >= r.end {
+		return false, nil
+	}
+	if presOffset+frameDuration <= r.start {
+		return true, nil
+	}
+
+	name := filepath.Join(r.dir, fmt.Sprintf("frame_%06d.rgba", r.frameCount))
+	err = os.WriteFile(name, frame.Data(), 0o644)
+	if err != nil {
+		return false, err
+	}
+	r.frameCount += 1
+	return true, nil
+}
+
+// Writes the part of the given audio frame that overlaps the recorded
+// window, and returns whether later frames can still overlap it.
+func (r *recorder) writeAudio(frame *reisen.AudioFrame) (bool, error) {
+	presOffset, err := frame.PresentationOffset()
+	if err != nil {
+		return false, err
+	}
+	if presOffset >= r.end {
+		return false, nil
+	}
+
+	// trim the data outside the window, in whole sample frames
+	data := frame.Data()
+	if presOffset < r.start {
+		skip := durationToAudioBytes(r.start-presOffset, r.sampleRate)
+		data = data[min(skip, len(data)):]
+		presOffset = r.start
+	}
+	keep := durationToAudioBytes(r.end-presOffset, r.sampleRate)
+	data = data[:min(keep, len(data))]
+
+	_, err = r.wav.Write(data)
+	if err != nil {
+		return false, err
+	}
+	r.audioBytes += len(data)
+	return presOffset+audioBytesToDuration(len(data), r.sampleRate) < r.end, nil
+}
+
+// Writes the WAV header with the final data size and closes the file.
+func (r *recorder) closeWAV() error {
+	header := make([]byte, 0, wavHeaderSize)
+	header = append(header, "RIFF"...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(wavHeaderSize-8+r.audioBytes))
+	header = append(header, "WAVEfmt "...)
+	header = binary.LittleEndian.AppendUint32(header, 16) // fmt chunk size
+	header = binary.LittleEndian.AppendUint16(header, 1)  // PCM
+	header = binary.LittleEndian.AppendUint16(header, audioChannelCount)
+	header = binary.LittleEndian.AppendUint32(header, uint32(r.sampleRate))
+	header = binary.LittleEndian.AppendUint32(header, uint32(r.sampleRate*audioBytesPerFrame))
+	header = binary.LittleEndian.AppendUint16(header, audioBytesPerFrame)
+	header = binary.LittleEndian.AppendUint16(header, audioBytesPerSample*8)
+	header = append(header, "data"...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(r.audioBytes))
+
+	_, err := r.wav.WriteAt(header, 0)
+	if err != nil {
+		_ = r.wav.Close()
+		return err
+	}
+	return r.wav.Close()
+}
+
+// Decodes the media from the current position up to the given duration and
+// writes the output to the given directory, which is created if necessary.
+// Each video frame is written as "frame_NNNNNN.rgba", with the raw RGBA
+// pixels at the [Player.Resolution]() (no header, 4 bytes per pixel), and
+// the audio is written to "audio.wav" as 16-bit stereo PCM. This is a
+// development tool, mainly intended for generating test fixtures.
+//
+// The media is decoded as fast as possible with a separate decoder, so the
+// playback is not affected. Frame processing, chroma keying, audio effects
+// and volume are not applied to the output. The call blocks until the whole
+// window has been written, and all the decoding resources are released
+// before returning. This is only available for players created from a file
+// or URL.
+func (p *Player) RecordTo(dir string, duration time.Duration) error {
+	if p.closed {
+		return ErrPlayerClosed
+	}
+	if p.source == "" || p.IsLive() {
+		return fmt.Errorf("recording: %w", ErrNoSource)
+	}
+	if duration <= 0 {
+		return fmt.Errorf("invalid recording duration %s", duration)
+	}
+	start, err := p.Position()
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return err
+	}
+
+	width, height := p.Resolution()
+	return recordMedia(p.source, dir, start, duration, width, height, p.options, p.frameDurationOverride)
+}
+
+// Implements [Player.RecordTo]() for the given source and window. A non-zero
+// frameDurationOverride replaces the frame duration of the video stream.
+func recordMedia(source, dir string, start, duration time.Duration, width, height int, opts PlayerOptions, frameDurationOverride time.Duration) error {
+	media, err := reisen.NewMedia(source)
+	if err != nil {
+		return err
+	}
+	defer media.Close()
+
+	rec := &recorder{dir: dir, start: start, end: start + duration}
+
+	// pick the same streams the player uses
+	var video *reisen.VideoStream
+	var frameDuration time.Duration
+	if videoStreams := media.VideoStreams(); len(videoStreams) > 0 {
+		video = videoStreams[0]
+		frameDuration = frameDurationOverride
+		if frameDuration == 0 {
+			frameDuration = nominalFrameDuration(video)
+		}
+		rec.videoActive = true
+	}
+	var audio *reisen.AudioStream
+	if audioStreams := media.AudioStreams(); len(audioStreams) > 0 && !opts.IgnoreAudio {
+		audio, err = selectAudioStream(audioStreams, opts)
+		if err != nil {
+			return err
+		}
+		rec.sampleRate = audio.SampleRate()
+		rec.audioActive = true
+	}
+	if video == nil && audio == nil {
+		return ErrNoVideo
+	}
+
+	// open decoders and move to the start of the window
+	err = media.OpenDecode()
+	if err != nil {
+		return err
+	}
+	defer media.CloseDecode()
+	if video != nil {
+		err = openVideoDecode(video, width, height)
+		if err != nil {
+			return err
+		}
+		defer video.Close()
+		err = video.Rewind(start)
+		if err != nil {
+			return err
+		}
+	}
+	if audio != nil {
+		err = audio.Open()
+		if err != nil {
+			return err
+		}
+		defer audio.Close()
+		err = audio.Rewind(start)
+		if err != nil {
+			return err
+		}
+
+		rec.wav, err = os.Create(filepath.Join(dir, "audio.wav"))
+		if err != nil {
+			return err
+		}
+		_, err = rec.wav.Write(make([]byte, wavHeaderSize)) // written on close
+		if err != nil {
+			_ = rec.wav.Close()
+			return err
+		}
+	}
+
+	err = rec.decode(media, video, audio, frameDuration)
+	if rec.wav != nil {
+		closeErr := rec.closeWAV()
+		if err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// Reads packets until both streams reach the end of the window.
+func (r *recorder) decode(media *reisen.Media, video *reisen.VideoStream, audio *reisen.AudioStream, frameDuration time.Duration) error {
+	for r.videoActive || r.audioActive {
+		packet, packetFound, err := media.ReadPacket()
+		if err != nil {
+			return err
+		}
+		if !packetFound {
+			return nil
+		}
+
+		switch {
+		case r.videoActive && packet.Type() == reisen.StreamVideo && packet.StreamIndex() == video.Index():
+			frame, _, err := video.ReadVideoFrame()
+			if err != nil {
+				return err
+			}
+			if frame != nil {
+				r.videoActive, err = r.writeFrame(frame, frameDuration)
+				if err != nil {
+					return err
+				}
+			}
+		case r.audioActive && packet.Type() == reisen.StreamAudio && packet.StreamIndex() == audio.Index():
+			frame, _, err := audio.ReadAudioFrame()
+			if err != nil {
+				return err
+			}
+			if frame != nil {
+				r.audioActive, err = r.writeAudio(frame)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
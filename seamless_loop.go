@@ -0,0 +1,150 @@
+package avebi
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/erparts/reisen"
+)
+
+// Enables or disables seamless looping. Regular looping (see
+// [Player.SetLooping]()) rewinds the decoders when the video reaches its
+// end, which can cause a short gap or glitch, especially in the audio. In
+// seamless mode, instead, a second decoder is kept ready at the loop start
+// (see [Player.SetLoopStart]()), and the player switches to it the moment
+// the current one reaches the end, so there's no seeking nor decoding latency
+// for the first frame of the loop. For videos with audio, though, a new audio
+// player is still created on each switch, like when looping regularly, so
+// the audio restarts with the same latency. The drawback is that twice the
+// decoding resources are used.
+//
+// Enabling seamless looping makes the video loop, and [Player.GetLooping]()
+// returns true while it's enabled. Disabling it, or calling SetLooping(false),
+// releases the second decoder and stops looping. Loops are reported through
+// [Player.OnLoop]() as usual, and the audio configuration of the player is
+// carried over on each switch.
+//
+// After each switch, the next decoder is prepared on the calling goroutine
+// during the following [Player.CurrentFrame]() call, which can make that
+// call take a while, but doesn't delay the first frame of the loop.
+// This is only available for players created from a file or URL, and not
+// for live streams. Enabling it while already enabled does nothing.
+func (p *Player) SetSeamlessLoop(enabled bool) error {
+	if p.closed {
+		return ErrPlayerClosed
+	}
+	if !enabled {
+		if !p.seamlessLoop {
+			return nil
+		}
+		p.seamlessLoop = false
+		return p.closeLoopSpare()
+	}
+	if p.seamlessLoop {
+		return nil
+	}
+	if p.source == "" || p.IsLive() {
+		return fmt.Errorf("seamless looping: %w", ErrNoSource)
+	}
+
+	err := p.prepareLoopSpare()
+	if err != nil {
+		return err
+	}
+	p.controller.SetLooping(false) // we handle the loops ourselves
+	p.seamlessLoop = true
+	return nil
+}
+
+// Returns whether seamless looping is enabled. See [Player.SetSeamlessLoop]().
+func (p *Player) GetSeamlessLoop() bool {
+	return p.seamlessLoop
+}
+
+// Opens a second controller for the source and leaves it paused at the
+// loop start, ready to replace the current one.
+func (p *Player) prepareLoopSpare() error {
+	name := filepath.Base(p.source)
+	container, err := reisen.NewMedia(p.source)
+	if err != nil {
+		return err
+	}
+	controller, _, err := newController(container, name, p.options, nil, p.logger)
+	if err != nil {
+		container.Close()
+		return err
+	}
+	p.applyEndBehavior(controller)
+	controller.SetErrorHandler(p.errorHandler)
+	p.applyFrameDurationOverride(controller)
+	loopStart := p.controller.GetLoopStart()
+	controller.SetLoopStart(loopStart)
+	if loopStart > 0 {
+		_, err = controller.Seek(loopStart)
+	} else {
+		_, err = controller.Prime()
+	}
+	if err != nil {
+		return errors.Join(err, controller.Close())
+	}
+
+	p.loopSpare = controller
+	return nil
+}
+
+// Releases the spare controller, if any.
+func (p *Player) closeLoopSpare() error {
+	if p.loopSpare == nil {
+		return nil
+	}
+	err := p.loopSpare.Close()
+	p.loopSpare = nil
+	return err
+}
+
+// Replaces the controller that reached the end with the spare one and starts
+// playing it. Returns the current frame of the new controller, like
+// CurrentVideoFrame(). The next spare is prepared on the next update instead,
+// see prepareDeferredLoopSpare(), so the frame at the loop boundary isn't
+// delayed.
+func (p *Player) swapLoopSpare() (*reisen.VideoFrame, bool, error) {
+	if p.loopSpare == nil { // e.g. a previous preparation failed
+		err := p.prepareLoopSpare()
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	prevController, controller := p.controller, p.loopSpare
+	p.loopSpare = nil
+	switch prev := prevController.(type) {
+	case *videoWithAudioController:
+		controller.(*videoWithAudioController).copyConfigFrom(prev)
+	case *videoOnlyController:
+		controller.(*videoOnlyController).copyConfigFrom(prev)
+	}
+
+	err := controller.Play()
+	p.controller = controller
+	p.loopsSeen = controllerLoopCount(controller) - 1 // counted by updateLoops()
+	err = errors.Join(err, prevController.Close())
+	if err != nil {
+		return nil, false, err
+	}
+	p.loopSparePending = true
+	return p.controller.CurrentVideoFrame()
+}
+
+// Prepares the spare controller requested by the last swapLoopSpare(), if
+// still needed. If this fails, the spare is prepared again when swapping.
+func (p *Player) prepareDeferredLoopSpare() error {
+	if !p.loopSparePending {
+		return nil
+	}
+	p.loopSparePending = false
+	if !p.seamlessLoop || p.loopSpare != nil {
+		return nil
+	}
+	return p.prepareLoopSpare()
+}
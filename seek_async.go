@@ -0,0 +1,110 @@
+package avebi
+
+import (
+	"errors"
+	"time"
+
+	"github.com/erparts/reisen"
+)
+
+// Reported through the [Player.SeekAsync]() callback when the seek was
+// superseded by a newer one before its frame could be presented.
+var ErrSeekSuperseded = errors.New("seek superseded by a newer one")
+
+// A completed asynchronous seek, waiting to be applied by CurrentFrame().
+type asyncSeekResult struct {
+	generation uint64
+	position   time.Duration // requested position
+	landed     time.Duration
+	frame      *reisen.VideoFrame
+	err        error
+	done       func(landed time.Duration, err error)
+}
+
+// Like [Player.Seek](), but returns immediately and seeks on a separate
+// goroutine, so the update loop doesn't block while scrubbing through slow
+// files. When the seek completes, the frame it landed on is presented by
+// the next [Player.CurrentFrame]() call, which then invokes done (if not
+// nil) with the position of that frame and the seek error, if any. Until
+// then, CurrentFrame() keeps returning the previous frame without querying
+// the decoder. Seeks that end up stopping the video, like seeking past the
+// end, report the resulting position instead.
+//
+// Seeks are coalesced: if a newer seek is requested, with SeekAsync() or
+// [Player.Seek](), before an asynchronous one takes effect, the older one
+// reports [ErrSeekSuperseded] instead, and a newer asynchronous seek also
+// discards the target of a previous [Player.Seek]() not performed yet. Callbacks for seeks still pending when the player is closed are
+// never invoked. Other methods that access the decoder, like
+// [Player.Position](), can block until the seek in progress completes.
+//
+// This must be called from the same goroutine as CurrentFrame().
+func (p *Player) SeekAsync(position time.Duration, done func(landed time.Duration, err error)) {
+	if p.closed {
+		if done != nil {
+			done(0, ErrPlayerClosed)
+		}
+		return
+	}
+
+	p.pendingSeekMutex.Lock()
+	generation := p.seekGeneration.Add(1)
+	p.hasPendingSeek = false
+	p.pendingSeekMutex.Unlock()
+	controller := p.controller
+	p.asyncSeeksPending += 1
+	go func() {
+		result := asyncSeekResult{generation: generation, position: position, done: done}
+		p.seekMutex.Lock()
+		if p.seekGeneration.Load() == generation { // otherwise superseded already
+			result.frame, result.err = controller.Seek(position)
+			if result.err == nil {
+				result.landed, result.err = seekLanding(controller, result.frame)
+			}
+		}
+		p.seekMutex.Unlock()
+
+		p.asyncSeekMutex.Lock()
+		p.asyncSeekResults = append(p.asyncSeekResults, result)
+		p.asyncSeekMutex.Unlock()
+	}()
+}
+
+// Returns the position where a seek landed, given the frame it returned.
+func seekLanding(controller VideoController, frame *reisen.VideoFrame) (time.Duration, error) {
+	if frame == nil { // audio-only media, or the video was stopped
+		return controller.Position()
+	}
+	return frame.PresentationOffset()
+}
+
+// Presents the frames of the completed asynchronous seeks that haven't
+// been superseded, and invokes their callbacks. See SeekAsync().
+func (p *Player) applyAsyncSeeks() {
+	p.asyncSeekMutex.Lock()
+	results := p.asyncSeekResults
+	p.asyncSeekResults = nil
+	p.asyncSeekMutex.Unlock()
+
+	for _, result := range results {
+		p.asyncSeeksPending -= 1
+		err := result.err
+		if err == nil && result.generation != p.seekGeneration.Load() {
+			err = ErrSeekSuperseded
+		}
+		if err == nil {
+			err = p.finishSeek(result.frame, result.position)
+		}
+		if result.done != nil {
+			result.done(result.landed, err)
+		}
+	}
+}
+
+// Supersedes the pending seeks and waits for the one in progress, if
+// any, so the controller can be safely replaced or closed.
+func (p *Player) cancelSeeks() {
+	p.discardPendingSeek()
+	p.seekGeneration.Add(1)
+	p.seekMutex.Lock()
+	p.seekMutex.Unlock()
+}
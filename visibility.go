@@ -0,0 +1,69 @@
+package avebi
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// Implemented by the controllers that decode frames on demand, so they can
+// skip the frames missed while the player was hidden. See [Player.SetVisible]().
+type gapSkipper interface {
+	skipNextGap()
+}
+
+// Tells the player whether its frames are being shown. While hidden,
+// [Player.CurrentFrame]() stops retrieving new frames and keeps returning
+// the last one, which saves the decoding and upload costs of videos that
+// are scrolled offscreen or fully occluded. Players are visible by default.
+//
+// Unlike [Player.Pause](), this is purely a resource hint: the playback
+// clock keeps running, the audio keeps playing and the playback state,
+// position, triggers and callbacks are updated as usual, so the rest of
+// the application doesn't need to know. When shown again, videos without
+// audio seek to the current position instead of decoding all the frames
+// missed in between, like with [CatchUpSeekOnLargeGap]. Videos with audio
+// and live streams still have to demux and decode the video along with
+// the rest of the media, so only the frame conversion and upload are saved
+// for them.
+func (p *Player) SetVisible(visible bool) {
+	if visible == !p.hidden {
+		return
+	}
+	p.hidden = !visible
+	if skipper, ok := p.controller.(gapSkipper); ok && visible {
+		skipper.skipNextGap()
+	}
+}
+
+// Returns whether the player is visible. See [Player.SetVisible]().
+func (p *Player) IsVisible() bool {
+	return !p.hidden
+}
+
+// Implements [Player.CurrentFrame]() while the player is hidden: the
+// playback state is updated, but no frames are requested.
+func (p *Player) currentHiddenFrame() (*ebiten.Image, error) {
+	// State() detects the end of the media as a side effect. if we
+	// were playing and no longer are, the end has been reached
+	prevState := p.peekState()
+	state, err := p.controller.State()
+	if err != nil {
+		return nil, err
+	}
+	if prevState == Playing && state != Playing {
+		if p.seamlessLoop {
+			_, _, err = p.swapLoopSpare()
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			p.reachedEnd = true
+		}
+	}
+
+	if err := p.updatePositionTriggers(); err != nil {
+		return nil, err
+	}
+	if err := p.updatePositionCallback(); err != nil {
+		return nil, err
+	}
+	p.updateLoops()
+	return p.currentFrame, nil
+}